@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/imroc/req/v3"
+	utls "github.com/refraction-networking/utls"
 )
 
 const defaultTimeout = 10 * time.Second
@@ -23,6 +24,23 @@ var (
 type Client struct {
 	req         *req.Client
 	errorMapper ErrorMapperFunc
+	tlsHello    *utls.ClientHelloID
+	pins        *pinSet
+	otel        *otelInstrumentation
+	logging     *logConfig
+	redact      *redaction
+	dumpOnError *dumpOnError
+	download    *downloadConfig
+	progress    ProgressRenderer
+	tus         *tusConfig
+	compress    *compressConfig
+	rateLimits  *rateLimits
+	// multipartBoundary generates boundaries for Multipart bodies.
+	multipartBoundary func() string
+	// multipartSized is set once sizeMultipartBodies wrapped the transport.
+	multipartSized bool
+	// perCall is set on the clone that request options are applied to.
+	perCall bool
 }
 
 // New creates a client with opinionated defaults and optional overrides.
@@ -72,7 +90,11 @@ func New(opts ...Option) *Client {
 		opt.applyClient(c)
 	}
 	if _, ok := os.LookupEnv("HTTP_TRACE"); ok {
-		c.req.EnableDumpAll()
+		if extra, enabled := traceRedaction(); enabled {
+			c.dumpAllRedacted(os.Stdout, func() *redaction { return c.redact.withHeaders(extra) })
+		} else {
+			c.req.EnableDumpAll()
+		}
 	}
 	return c
 }
@@ -102,10 +124,28 @@ func (c *Client) clone() *Client {
 	if c == nil {
 		return New()
 	}
-	return &Client{
-		req:         c.req.Clone(),
-		errorMapper: c.errorMapper,
+	cc := &Client{
+		req:               c.req.Clone(),
+		errorMapper:       c.errorMapper,
+		tlsHello:          c.tlsHello,
+		pins:              c.pins,
+		otel:              c.otel,
+		logging:           c.logging,
+		redact:            c.redact,
+		dumpOnError:       c.dumpOnError,
+		download:          c.download,
+		progress:          c.progress,
+		tus:               c.tus,
+		compress:          c.compress,
+		rateLimits:        c.rateLimits,
+		multipartBoundary: c.multipartBoundary,
+		multipartSized:    c.multipartSized,
+		perCall:           true,
 	}
+	// The handshake reads TLS settings from the transport it was installed on,
+	// so rebind it to the cloned transport.
+	cc.installTLSHandshake()
+	return cc
 }
 
 // Get issues a GET request using the provided client.
@@ -489,9 +529,7 @@ func Do[T any](r *req.Request) (T, *req.Response, error) {
 	return out, resp, newHTTPError(resp)
 }
 
-func do[T any](client *Client, ctx context.Context, method, url string, body any, opts []Option) (T, *req.Response, error) {
-	var out T
-
+func do[T any](client *Client, ctx context.Context, method, url string, body any, opts []Option) (out T, resp *req.Response, err error) {
 	if client == nil {
 		client = Default()
 	}
@@ -509,6 +547,7 @@ func do[T any](client *Client, ctx context.Context, method, url string, body any
 	if ctx != nil {
 		req.SetContext(ctx)
 	}
+	bindClient(req, client)
 	if body != nil {
 		setBody(req, body)
 	}
@@ -524,7 +563,12 @@ func do[T any](client *Client, ctx context.Context, method, url string, body any
 		req.SetSuccessResult(&out)
 	}
 
-	resp, err := send(req, method, url)
+	endDump := client.startDumpOnError(req)
+	defer func() { endDump(resp, err) }()
+
+	finish := client.startCall(req, method, url)
+	resp, err = send(req, method, url)
+	finish(resp, err)
 	if err != nil {
 		if resp != nil && resp.IsSuccessState() && rawKind == rawNone && isEmptyBody(resp) {
 			ensureNonNil(&out)
@@ -552,6 +596,24 @@ func (c *Client) mapError(resp *req.Response) error {
 	return newHTTPError(resp)
 }
 
+type clientKey struct{}
+
+// bindClient records the client a request was built from. Transport wrappers
+// are shared between a client and its per-request clones, so they use
+// boundClient to read settings from the clone that actually sent the request.
+func bindClient(r *req.Request, c *Client) {
+	r.SetContext(context.WithValue(r.Context(), clientKey{}, c))
+}
+
+// boundClient returns the client bound to r, or fallback for requests built
+// directly on the underlying req client.
+func boundClient(r *req.Request, fallback *Client) *Client {
+	if c, ok := r.Context().Value(clientKey{}).(*Client); ok {
+		return c
+	}
+	return fallback
+}
+
 const (
 	methodGet     = "GET"
 	methodPost    = "POST"
@@ -66,6 +66,11 @@ func run() error {
 
 		for name, fd := range extractFuncDocs(fset, filename, file) {
 			if existing, ok := funcs[name]; ok {
+				// Methods such as Error share a name across types; keep the
+				// description of the declaration that documents examples.
+				if len(existing.Examples) == 0 && len(fd.Examples) > 0 {
+					existing.Description = fd.Description
+				}
 				existing.Examples = append(existing.Examples, fd.Examples...)
 			} else {
 				funcs[name] = fd
@@ -402,6 +407,11 @@ func writeMain(base string, fd *FuncDoc, importPath string) error {
 		"http2.":     "github.com/imroc/req/v3/http2",
 		"rand.":      "crypto/rand",
 		"base64.":    "encoding/base64",
+		"strconv.":   "strconv",
+		"ed25519.":   "crypto/ed25519",
+		"tls.":       "crypto/tls",
+		"otel.":      "go.opentelemetry.io/otel",
+		"slog.":      "log/slog",
 	}
 
 	for _, ex := range fd.Examples {
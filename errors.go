@@ -12,6 +12,8 @@ type HTTPError struct {
 	Status     string
 	Body       []byte
 	Header     http.Header
+	// Timing is the timing breakdown of the attempt that produced the error.
+	Timing Timing
 }
 
 // Error returns a short, human-friendly summary of the HTTP error.
@@ -44,5 +46,6 @@ func newHTTPError(resp *req.Response) *HTTPError {
 		Status:     resp.Status,
 		Body:       resp.Bytes(),
 		Header:     resp.Header,
+		Timing:     TimingOf(resp),
 	}
 }
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// AtomicDownload makes OutputFile and Download write to a temporary file in
+	// the destination directory, fsync it and rename it into place only once the
+	// transfer succeeds, so the destination never holds a partial or failed body.
+	// OutputFile removes the temporary file on failure; Download keeps it as
+	// dest+".part" so the next call can resume.
+
+	// Example: never leave a half-written file behind
+	c := httpx.New(httpx.AtomicDownload())
+	_, err := httpx.Get[[]byte](c, "https://httpbin.org/bytes/1024", httpx.OutputFile("/tmp/file.bin"))
+	fmt.Println(err == nil)
+	// true
+}
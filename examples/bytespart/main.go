@@ -0,0 +1,16 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// BytesPart returns a file part holding content.
+
+	// Example: a file from memory
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+		httpx.Multipart(httpx.BytesPart("file", "report.txt", []byte("hello"))),
+	)
+}
@@ -0,0 +1,22 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"crypto/tls"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// CipherSuites restricts the TLS 1.0-1.2 cipher suites, as in tls.Config.CipherSuites.
+	// With a TLSFingerprint preset the offered list is part of the fingerprint, so
+	// connections that negotiate a suite outside the list are rejected after the handshake.
+
+	// Example: allow only ECDHE AES-GCM suites
+	c := httpx.New(httpx.CipherSuites(
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	))
+	_ = c
+}
@@ -0,0 +1,15 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// ClientCert presents a client certificate loaded from PEM files for mutual TLS.
+	// A certificate that fails to load is reported as an error on every request.
+
+	// Example: mutual TLS with certificate files
+	c := httpx.New(httpx.ClientCert("client.crt", "client.key"))
+	_ = c
+}
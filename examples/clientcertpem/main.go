@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// ClientCertPEM presents a client certificate from PEM-encoded bytes for mutual TLS.
+
+	// Example: mutual TLS with in-memory PEM
+	certPEM, _ := os.ReadFile("client.crt")
+	keyPEM, _ := os.ReadFile("client.key")
+	c := httpx.New(httpx.ClientCertPEM(certPEM, keyPEM))
+	_ = c
+}
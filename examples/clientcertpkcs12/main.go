@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// ClientCertPKCS12 presents a client certificate from a PKCS#12 (.p12/.pfx) bundle.
+	// Intermediate certificates in the bundle are sent along with the leaf.
+
+	// Example: mutual TLS with a PKCS#12 bundle
+	pfx, _ := os.ReadFile("client.p12")
+	c := httpx.New(httpx.ClientCertPKCS12(pfx, "changeit"))
+	_ = c
+}
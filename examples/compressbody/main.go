@@ -0,0 +1,28 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// CompressBody compresses request bodies with algo and sets Content-Encoding.
+	// Level 0 selects the default level of the algorithm; otherwise it ranges
+	// from 1 to 9 for gzip, 1 to 22 for zstd and 1 to 11 for brotli. Bodies are
+	// encoded while they are sent, so they are not held in memory, and every
+	// attempt, including retries, encodes the body again. Bodies under 1 KiB are
+	// sent as they are, see CompressBodyMinSize, as are bodies that already have
+	// a Content-Encoding.
+
+	// Example: gzip large JSON batches
+	batch := []map[string]any{{"event": "signup"}, {"event": "login"}}
+
+	// Apply to all requests
+	c := httpx.New(httpx.CompressBody(httpx.CompressionGzip, 0))
+	_, _ = httpx.Post[[]map[string]any, map[string]any](c, "https://example.com/ingest", batch)
+
+	// Apply to a single request
+	_, _ = httpx.Post[[]map[string]any, map[string]any](c, "https://example.com/ingest", batch,
+		httpx.CompressBody(httpx.CompressionZstd, 3),
+	)
+}
@@ -0,0 +1,16 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// CompressBodyMinSize sets the smallest body, in bytes, that CompressBody
+	// encodes. Smaller bodies gain little and are sent as they are. The default
+	// is 1 KiB.
+
+	// Example: compress every body
+	c := httpx.New(httpx.CompressBody(httpx.CompressionBrotli, 0), httpx.CompressBodyMinSize(0))
+	_, _ = httpx.Post[map[string]any, map[string]any](c, "https://example.com/ingest", map[string]any{"ok": true})
+}
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// ContentDigest sets an RFC 9530 Content-Digest header computed from the request body.
+	// Defaults to sha-256 when no algorithms are given.
+
+	// Example: send a content digest
+	c := httpx.New()
+	res, _ := httpx.Post[string, map[string]any](c, "https://httpbin.org/post", "hello",
+		httpx.ContentDigest(httpx.DigestSHA256, httpx.DigestSHA512),
+	)
+	httpx.Dump(res) // dumps map[string]any
+	// #map[string]interface {} {
+	//   headers => #map[string]interface {} {
+	//     Content-Digest => "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:, sha-512=:<digest>:" #string
+	//   }
+	// }
+}
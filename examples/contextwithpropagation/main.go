@@ -0,0 +1,24 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"net/http"
+)
+
+func main() {
+	// ContextWithPropagation returns a copy of ctx carrying the correlation headers
+	// of r, chosen the same way as PropagateFrom. Requests sent with that context by
+	// a client using PropagateFromContext forward them. It suits middleware that
+	// prepares the context once for all outgoing calls of a handler.
+
+	// Example: capture headers in middleware
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(httpx.ContextWithPropagation(r.Context(), r)))
+		})
+	}
+	_ = middleware
+}
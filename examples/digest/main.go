@@ -0,0 +1,25 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// Digest enables HTTP Digest authentication (RFC 7616).
+	// A 401 challenge is answered by replaying the request with credentials, and the
+	// challenge is cached per origin and realm so later requests in the same
+	// protection space authenticate up front with an incrementing nonce count.
+	// SHA-512, SHA-512-256, SHA-256 and MD5 are supported (the strongest offered
+	// challenge wins, in that order) with qop=auth or qop=auth-int. Request bodies are buffered so
+	// they can be replayed after a challenge.
+
+	// Example: digest auth
+	c := httpx.New(httpx.Digest("user", "pass"))
+	res, _ := httpx.Get[map[string]any](c, "https://httpbin.org/digest-auth/auth/user/pass/SHA-256")
+	httpx.Dump(res) // dumps map[string]any
+	// #map[string]interface {} {
+	//   authenticated => true #bool
+	//   user          => "user" #string
+	// }
+}
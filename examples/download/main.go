@@ -0,0 +1,37 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// Download fetches url into dest and can be called again to continue an
+	// interrupted transfer. While a download is incomplete, dest sits next to a
+	// dest+".resume" file holding the resource's validator; the next call sends
+	// Range and If-Range so the server returns only the missing bytes, or the
+	// whole file if it changed. A resource without a strong ETag or Last-Modified
+	// is downloaded again from the start. An existing dest without a resume file
+	// is replaced. With DownloadSegments, the file is fetched as concurrent byte
+	// ranges when the server advertises Accept-Ranges, and as a single stream
+	// otherwise. Header, auth and other request options apply to every request;
+	// the client timeout bounds each request, so use Timeout(0) for large files.
+
+	// Example: resumable download
+	c := httpx.New(httpx.Timeout(0))
+	err := httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso")
+	fmt.Println(err == nil)
+	// true
+
+	// Example: parallel segments with progress
+	_ = httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso",
+		httpx.DownloadSegments(4).
+			DownloadCallback(func(info httpx.DownloadInfo) {
+				fmt.Printf("\r%d/%d", info.DownloadedSize, info.TotalSize)
+			}),
+	)
+}
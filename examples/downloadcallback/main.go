@@ -0,0 +1,28 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// DownloadCallback registers a callback for download progress. It reports
+	// bodies saved with OutputFile, bodies decoded in memory and Download, where
+	// segments report into one running total. Events arrive at most every 200ms,
+	// and a final 100% event is always delivered once the body has been read.
+
+	// Example: track download progress
+	c := httpx.New()
+	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576",
+		httpx.OutputFile("/tmp/file.bin"),
+		httpx.DownloadCallback(func(info httpx.DownloadInfo) {
+			fmt.Printf("\r%d/%d bytes, %.0f B/s, ETA %s", info.DownloadedSize, info.TotalSize, info.Rate, info.ETA)
+			if info.TotalSize > 0 && info.DownloadedSize >= info.TotalSize {
+				fmt.Print("\n")
+			}
+		}),
+	)
+}
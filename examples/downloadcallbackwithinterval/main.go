@@ -0,0 +1,28 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"time"
+)
+
+func main() {
+	// DownloadCallbackWithInterval registers a callback for download progress
+	// that fires at most once per minInterval. The final 100% event is always
+	// delivered.
+
+	// Example: throttle download progress updates
+	c := httpx.New()
+	res, _ := httpx.Get[map[string]any](c, "https://httpbin.org/get",
+		httpx.DownloadCallbackWithInterval(func(info httpx.DownloadInfo) {
+			fmt.Printf("\rprogress: %d bytes", info.DownloadedSize)
+		}, 200*time.Millisecond),
+	)
+	httpx.Dump(res) // dumps map[string]any
+	// #map[string]interface {} {
+	//   url => "https://httpbin.org/get" #string
+	// }
+}
@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// DownloadProgress shows download progress with the transfer rate and
+	// estimated time left. Output goes to the renderer set with ProgressTo, or
+	// else to stdout, as bars on a terminal and as log lines otherwise.
+
+	// Example: download with automatic progress
+	c := httpx.New()
+	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576",
+		httpx.OutputFile("/tmp/file.bin"),
+		httpx.DownloadProgress(),
+	)
+}
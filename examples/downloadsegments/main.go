@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// DownloadSegments splits Download into n concurrent byte-range requests when
+	// the server advertises Accept-Ranges and reports the size. Files too small to
+	// benefit use fewer segments; other servers are read as a single stream.
+
+	// Example: four parallel connections
+	c := httpx.New(httpx.Timeout(0))
+	_ = httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso", httpx.DownloadSegments(4))
+}
@@ -7,6 +7,7 @@ import "github.com/goforj/httpx/v2"
 
 func main() {
 	// DumpAll enables req's client-level dump output for all requests.
+	// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 
 	// Example: dump every request and response
 	c := httpx.New(httpx.DumpAll())
@@ -10,6 +10,7 @@ import (
 
 func main() {
 	// DumpEachRequestTo enables request-level dumps for each request and writes them to the provided output.
+	// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 
 	// Example: dump each request to a buffer
 	var buf bytes.Buffer
@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// DumpOnError captures the request and response dumps of each call in a
+	// bounded in-memory buffer and writes them to output only when the call ends
+	// in an error: an HTTP error status, a decode error or a transport error.
+	// Successful calls are discarded unless DumpOnErrorSample selects them. Dumps
+	// of every attempt, including retries, are kept, and secrets are masked as
+	// described by RedactHeaders, RedactQuery and RedactJSONFields. Only calls made
+	// through the request helpers (Get, Post, GetCtx, ...) are captured. Dumps
+	// requested with EnableDump, DumpTo or DumpToFile on the same call are still
+	// written as usual.
+
+	// Example: dump failed calls to stderr
+	c := httpx.New(httpx.DumpOnError(os.Stderr))
+	_, err := httpx.Get[string](c, "https://httpbin.org/status/500")
+	_ = err
+}
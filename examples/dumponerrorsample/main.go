@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// DumpOnErrorSample also writes the dumps of successful calls captured by
+	// DumpOnError, for the given fraction of them between 0 and 1. It may be
+	// given before or after DumpOnError.
+
+	// Example: keep one in a hundred successful exchanges
+	c := httpx.New(httpx.DumpOnError(os.Stderr).DumpOnErrorSample(0.01))
+	_ = c
+}
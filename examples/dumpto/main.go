@@ -10,6 +10,7 @@ import (
 
 func main() {
 	// DumpTo enables req's request-level dump output to a writer.
+	// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 
 	// Example: dump to a buffer
 	var buf bytes.Buffer
@@ -7,6 +7,7 @@ import "github.com/goforj/httpx/v2"
 
 func main() {
 	// DumpToFile enables req's request-level dump output to a file path.
+	// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 
 	// Example: dump to a file
 	c := httpx.New()
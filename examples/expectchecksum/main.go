@@ -0,0 +1,27 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// ExpectChecksum verifies files written by OutputFile and Download against a
+	// hex-encoded sha-256 or sha-512 sum and implies AtomicDownload. A mismatch
+	// is reported as a *ChecksumError and the file is not put in place. An
+	// invalid sum is reported as an error on every request.
+
+	// Example: verify a release archive
+	c := httpx.New(httpx.Timeout(0))
+	err := httpx.Download(context.Background(), c, "https://example.com/app.tar.gz", "/tmp/app.tar.gz",
+		httpx.ExpectChecksum(httpx.DigestSHA256, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"),
+	)
+	var sumErr *httpx.ChecksumError
+	fmt.Println(errors.As(err, &sumErr))
+	// false
+}
@@ -0,0 +1,16 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// FieldPart returns a form field part.
+
+	// Example: a form field
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+		httpx.Multipart(httpx.FieldPart("title", "Quarterly report")),
+	)
+}
@@ -0,0 +1,17 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// FilePart returns a file part read from path while the request is sent. The
+	// file is opened again for each attempt, so parts survive retries.
+
+	// Example: a file from disk
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+		httpx.Multipart(httpx.FilePart("video", "/tmp/video.mp4").WithContentType("video/mp4")),
+	)
+}
@@ -0,0 +1,28 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"net/http"
+)
+
+func main() {
+	// HandlerTransport serves every request with h in-process instead of over
+	// the network. Connections are in-memory pipes carrying real HTTP/1.1, so
+	// streaming bodies, trailers, cancellation, retries, dumps and tracing
+	// behave as they do against a remote server. Both http and https URLs are
+	// served in plain text; no sockets are opened and no proxy is used.
+
+	// Example: call a handler without a server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /hello", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hi"))
+	})
+	c := httpx.New(httpx.HandlerTransport(mux), httpx.BaseURL("http://app.local"))
+	res, _ := httpx.Get[string](c, "/hello")
+	fmt.Println(res)
+	// hi
+}
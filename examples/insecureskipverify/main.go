@@ -0,0 +1,17 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// InsecureSkipVerify disables server certificate verification.
+	// This accepts any certificate presented by the server, including ones forged by
+	// an attacker, so a warning is logged whenever it is applied. Use RootCAs to
+	// trust a private CA instead.
+
+	// Example: talk to a local self-signed server
+	c := httpx.New(httpx.InsecureSkipVerify())
+	_ = c
+}
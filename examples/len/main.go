@@ -0,0 +1,17 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// Len returns the number of recorded entries.
+
+	// Example: count recorded entries
+	rec := httpx.NewHARRecorder()
+	fmt.Println(rec.Len()) // 0
+}
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// LimitDownloadRate caps how fast response bodies are read, in bytes per
+	// second. A client-level limit is one bucket shared by all requests of the
+	// client, including the segments of a Download; a request-level limit applies
+	// on top of it. Progress callbacks see the throttled pace, and waiting for the
+	// bucket stops when the request context is done. A rate of zero or less sets
+	// no limit.
+
+	// Example: download without saturating the link
+	// Apply to all requests
+	c := httpx.New(httpx.LimitDownloadRate(2 << 20))
+	_, _ = httpx.Get[[]byte](c, "https://example.com/large.bin")
+
+	// Apply to a single request
+	_, _ = httpx.Get[[]byte](c, "https://example.com/large.bin", httpx.LimitDownloadRate(512<<10))
+}
@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// LimitUploadRate caps how fast request bodies are sent, in bytes per second.
+	// A client-level limit is one bucket shared by all requests of the client, so
+	// concurrent uploads split the rate between them; a request-level limit
+	// applies on top of it. Progress callbacks see the throttled pace, and waiting
+	// for the bucket stops when the request context is done. A rate of zero or
+	// less sets no limit.
+
+	// Example: keep background uploads under 1 MiB/s
+	// Apply to all requests
+	c := httpx.New(httpx.LimitUploadRate(1 << 20))
+	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/sync", nil, httpx.File("file", "/tmp/backup.tar"))
+
+	// Apply to a single request
+	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/sync", nil,
+		httpx.File("file", "/tmp/backup.tar"),
+		httpx.LimitUploadRate(256<<10),
+	)
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	// LogBodies adds request and response bodies, truncated to maxBytes, to debug
+	// records written by Logger, with RedactJSONFields applied. Bodies streamed
+	// from an io.Reader are not logged.
+
+	// Example: include the first kilobyte of bodies at debug level
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := httpx.New(httpx.Logger(logger, slog.LevelDebug).LogBodies(1024))
+	_ = c
+}
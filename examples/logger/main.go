@@ -0,0 +1,25 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"log/slog"
+)
+
+func main() {
+	// Logger emits one structured record per attempt, including retries, with the
+	// method, URL, status, duration, bytes sent and received, attempt number and,
+	// for failures, the error and an error class (timeout, canceled, dns, tls,
+	// transport, client_error or server_error). Failed attempts are logged at
+	// slog.LevelWarn or above. When the logger is enabled for debug the record
+	// also carries request and response headers, and bodies if LogBodies is set.
+	// Header values and query parameters are masked as configured by
+	// RedactHeaders and RedactQuery. A nil logger uses slog.Default().
+
+	// Example: log every attempt
+	c := httpx.New(httpx.Logger(slog.Default(), slog.LevelInfo))
+	res, _ := httpx.Get[string](c, "https://httpbin.org/get")
+	httpx.Dump(res) // dumps string
+}
@@ -0,0 +1,33 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"crypto/ed25519"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// MessageSignature signs every attempt with an RFC 9421 HTTP message signature.
+	// Components are derived component names (such as @method, @target-uri,
+	// @authority, @path and @query) or lowercase header names; they default to
+	// @method, @target-uri and content-digest. When content-digest is covered and
+	// not already set, a sha-256 Content-Digest is computed from the request body.
+	// The key is an ed25519.PrivateKey, *ecdsa.PrivateKey, *rsa.PrivateKey or an
+	// HMAC secret as []byte, matching alg.
+
+	// Example: sign requests with ed25519
+	_, priv, _ := ed25519.GenerateKey(nil)
+	c := httpx.New(httpx.MessageSignature("partner-key", httpx.SignatureEd25519, priv,
+		"@method", "@target-uri", "content-type", "content-digest",
+	))
+	res, _ := httpx.Post[map[string]any, map[string]any](c, "https://httpbin.org/post", map[string]any{"amount": 10})
+	httpx.Dump(res) // dumps map[string]any
+	// #map[string]interface {} {
+	//   headers => #map[string]interface {} {
+	//     Signature => "sig1=:<signature>:" #string
+	//     Signature-Input => "sig1=(\"@method\" \"@target-uri\" \"content-type\" \"content-digest\");created=<unix>;keyid=\"partner-key\";alg=\"ed25519\"" #string
+	//   }
+	// }
+}
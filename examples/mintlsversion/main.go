@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"crypto/tls"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// MinTLSVersion sets the minimum TLS version, such as tls.VersionTLS12.
+	// With a TLSFingerprint preset the version offered is part of the fingerprint,
+	// so connections that negotiate a lower version are rejected after the handshake.
+
+	// Example: require TLS 1.3
+	c := httpx.New(httpx.MinTLSVersion(tls.VersionTLS13))
+	_ = c
+}
@@ -0,0 +1,28 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// Multipart sends parts as a multipart/form-data body, in the given order.
+	// Parts are streamed while the request is sent, so memory use does not grow
+	// with their size, and the request carries a Content-Length when the size of
+	// every part is known. Multipart replaces any other request body.
+
+	// Example: fields and files in one request
+	c := httpx.New()
+	res, _ := httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+		httpx.Multipart(
+			httpx.FieldPart("title", "Quarterly report"),
+			httpx.FilePart("file", "/tmp/report.pdf").WithContentType("application/pdf"),
+		),
+	)
+	httpx.Dump(res) // dumps map[string]any
+	// #map[string]interface {} {
+	//   form => #map[string]interface {} {
+	//     title => "Quarterly report" #string
+	//   }
+	// }
+}
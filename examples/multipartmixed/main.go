@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// MultipartMixed sends parts as a multipart/mixed body, in the given order.
+	// Parts with a file name are marked as attachments. Parts stream as with
+	// Multipart.
+
+	// Example: a batch of documents
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/batch", nil,
+		httpx.MultipartMixed(
+			httpx.FieldPart("", "first").WithContentType("text/plain"),
+			httpx.BytesPart("", "second.txt", []byte("second")),
+		),
+	)
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// MultipartRelated sends parts as a multipart/related body (RFC 2387), in
+	// the given order. The first part is the root, and its Content-Type becomes
+	// the type parameter of the body. Parts stream as with Multipart.
+
+	// Example: metadata and media in one request
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/upload?uploadType=multipart", nil,
+		httpx.MultipartRelated(
+			httpx.FieldPart("", `{"name":"video.mp4"}`).WithContentType("application/json"),
+			httpx.FilePart("", "/tmp/video.mp4").WithContentType("video/mp4"),
+		),
+	)
+}
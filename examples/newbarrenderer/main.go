@@ -0,0 +1,20 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// NewBarRenderer returns a renderer that draws one progress bar per active
+	// transfer and redraws them in place with ANSI escapes. Bars fill the width
+	// of the terminal when w is one, and 80 columns otherwise. Once every
+	// transfer is done the bars are left on screen and the next ones start below.
+
+	// Example: bars on the terminal
+	r := httpx.NewBarRenderer(os.Stdout)
+	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 1024, Total: 1024, Done: true})
+}
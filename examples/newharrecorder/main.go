@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// NewHARRecorder returns a recorder that keeps every entry in memory.
+
+	// Example: record a session and save it
+	rec := httpx.NewHARRecorder()
+	c := httpx.New(httpx.AsChrome().RecordHAR(rec))
+	_, _ = httpx.Get[string](c, "https://httpbin.org/get")
+	f, _ := os.Create("session.har")
+	defer f.Close()
+	_, _ = rec.WriteTo(f)
+}
@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// NewJSONRenderer returns a renderer that writes each event as a JSON object
+	// on its own line, for consumption by other programs.
+
+	// Example: machine-readable progress
+	r := httpx.NewJSONRenderer(os.Stdout)
+	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 512, Total: 1024})
+	// {"id":1,"label":"download","transferred":512,"total":1024,"rate":0,"eta_seconds":0,"done":false}
+}
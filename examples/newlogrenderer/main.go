@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+	"time"
+)
+
+func main() {
+	// NewLogRenderer returns a renderer that prints plain lines, suitable for log
+	// files and CI output. Each transfer prints at most one line per interval,
+	// plus its first and last.
+
+	// Example: log progress every five seconds
+	r := httpx.NewLogRenderer(os.Stderr, 5*time.Second)
+	r.Render(httpx.ProgressEvent{ID: 1, Label: "upload", Transferred: 512, Total: 1024})
+	// upload 50.0% 512 B/1.0 KiB 0 B/s
+}
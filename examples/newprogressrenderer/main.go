@@ -0,0 +1,18 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// NewProgressRenderer returns a bar renderer when w is a terminal and a log
+	// renderer printing a line per second otherwise.
+
+	// Example: pick the format for the current output
+	r := httpx.NewProgressRenderer(os.Stdout)
+	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 512, Total: 1024})
+}
@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// NewRollingHARRecorder returns a recorder that keeps the most recent
+	// maxEntries entries and rewrites path after every entry, so the file always
+	// holds a complete document even if the process dies. The file is replaced
+	// atomically, outside the lock requests record under. A maxEntries of zero or
+	// less keeps the last 1000 entries.
+
+	// Example: keep the last 500 exchanges on disk
+	rec := httpx.NewRollingHARRecorder("scrape.har", 500)
+	c := httpx.New(httpx.RecordHAR(rec))
+	_ = c
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// NewTusFileStore returns a TusStore kept as a JSON file at path, which is
+	// created on first use.
+
+	// Example: persist upload URLs
+	store := httpx.NewTusFileStore("/var/lib/myapp/uploads.json")
+	_ = store.Set("fingerprint", "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+	uploadURL, _ := store.Get("fingerprint")
+	fmt.Println(uploadURL)
+	// https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// NewTusMemoryStore returns a TusStore that lives as long as the process,
+	// which lets uploads resume after a failed TusUpload call.
+
+	// Example: resume within a process
+	store := httpx.NewTusMemoryStore()
+	_ = store.Set("fingerprint", "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+	_, ok := store.Get("fingerprint")
+	fmt.Println(ok)
+	// true
+}
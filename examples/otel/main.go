@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	// OTel instruments the client with OpenTelemetry tracing and metrics.
+	// Each call made through the request helpers (Get, Post, GetCtx, ...) gets a
+	// client span, and every attempt, including retries, gets a child span carrying
+	// http.request.resend_count. Attributes follow the HTTP semantic conventions,
+	// W3C trace context and baggage are injected into outgoing headers, and the
+	// http.client.request.duration, http.client.request.body.size and
+	// http.client.response.body.size histograms are recorded per attempt.
+	// Nil providers fall back to the global ones. Requests sent through Do or
+	// Req() only get attempt spans.
+
+	// Example: trace and meter a client
+	c := httpx.New(httpx.OTel(otel.GetTracerProvider(), otel.GetMeterProvider()))
+	res, _ := httpx.Get[string](c, "https://httpbin.org/get")
+	httpx.Dump(res) // dumps string
+}
@@ -0,0 +1,22 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// OutputCollision sets what OutputDir does when a file with the chosen name
+	// already exists. The default is CollisionSuffix.
+
+	// Example: never replace a file
+	// Apply to all requests
+	c := httpx.New(httpx.OutputCollision(httpx.CollisionFail))
+
+	// Apply to a single request
+	_, err := httpx.Get[[]byte](c, "https://example.com/reports/latest",
+		httpx.OutputDir("/tmp/reports"),
+		httpx.OutputCollision(httpx.CollisionOverwrite),
+	)
+	_ = err
+}
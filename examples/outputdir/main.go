@@ -0,0 +1,32 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// OutputDir streams the response body into a file in dir and names it after
+	// the response: the Content-Disposition filename (RFC 6266, preferring the
+	// UTF-8 filename* form), else the last segment of the final URL path, else
+	// "download". A name without an extension gets one from the Content-Type.
+	// Names are reduced to a single safe path segment, so a server cannot write
+	// outside dir. The body is written to a temporary file and renamed into place
+	// once the request succeeds; what happens when the name is taken is set by
+	// OutputCollision. OutputPathOf returns the path that was chosen. A
+	// client-level OutputDir does not apply to requests that use OutputFile, nor
+	// to Download and TusUpload.
+
+	// Example: save reports under the names the server gives them
+	// Apply to all requests
+	c := httpx.New(httpx.OutputDir("/tmp/reports"))
+	_, resp, _ := httpx.Do[[]byte](c.Req().R().SetURL("https://example.com/reports/latest"))
+	fmt.Println(httpx.OutputPathOf(resp))
+	// /tmp/reports/q3-2025.pdf
+
+	// Apply to a single request
+	_, _ = httpx.Get[[]byte](httpx.New(), "https://example.com/reports/latest", httpx.OutputDir("/tmp/reports"))
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// OutputPathOf returns the path OutputDir saved the body of resp to, or ""
+	// when the request did not use OutputDir or did not succeed.
+
+	// Example: find the saved file
+	c := httpx.New(httpx.OutputDir("/tmp"))
+	_, resp, err := httpx.Do[[]byte](c.Req().R().SetURL("https://example.com/export"))
+	if err == nil {
+		fmt.Println(httpx.OutputPathOf(resp))
+	}
+}
@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// PinSPKI pins the public keys a host may present, as base64 SHA-256 hashes of
+	// the certificates' SubjectPublicKeyInfo (the HPKP pin-sha256 format).
+	// The handshake fails with a *PinningError unless some certificate in a
+	// verified chain, leaf, intermediate or root, matches one of the pins; with
+	// InsecureSkipVerify only the leaf can match. Listing a backup pin
+	// alongside the current one allows keys to rotate without an outage.
+	// Host may be an exact name or a "*.example.com" wildcard covering subdomains;
+	// hosts without pins are not checked. Calling PinSPKI again for a host replaces
+	// its pins. A pin can be computed with
+	// openssl x509 -pubkey -noout -in cert.pem | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64.
+
+	// Example: pin a processor's intermediate with a backup key
+	c := httpx.New(httpx.PinSPKI("api.payments.example",
+		"r/mIkG3eEpVdm+u/ko/cwxzOMo1bk4TyHIlByibiA5E=", // current intermediate
+		"YLh1dUR9y6Kja30RrAn7JKnbQG/uEtLMkBgFF2Fuihg=", // backup key
+	))
+	_ = c
+}
@@ -0,0 +1,24 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// PinSPKIReportOnly switches pin checks to report-only mode: a mismatch calls
+	// report with the *PinningError and the connection proceeds. Use it to roll
+	// out new pins safely before enforcing them.
+
+	// Example: report pin mismatches without failing
+	c := httpx.New(httpx.
+		PinSPKI("api.payments.example", "r/mIkG3eEpVdm+u/ko/cwxzOMo1bk4TyHIlByibiA5E=").
+		PinSPKIReportOnly(func(err *httpx.PinningError) {
+			fmt.Printf("pin mismatch: %v (chain %v)\n", err, err.Chain)
+		}),
+	)
+	_ = c
+}
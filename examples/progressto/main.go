@@ -0,0 +1,18 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// ProgressTo sends the output of UploadProgress and DownloadProgress to r
+	// instead of stdout.
+
+	// Example: render progress on stderr
+	c := httpx.New(httpx.ProgressTo(httpx.NewProgressRenderer(os.Stderr)))
+	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576", httpx.DownloadProgress())
+}
@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"net/http"
+)
+
+func main() {
+	// PropagateFrom forwards correlation headers from an incoming server request.
+	// By default traceparent, tracestate, baggage and X-Request-ID are copied;
+	// passing header names replaces that allowlist. When X-Request-ID is allowed
+	// but missing from the incoming request a new ID is generated once, so every
+	// request sent with the option shares it. No OpenTelemetry setup is needed;
+	// when OTel is also configured its injected trace context takes precedence.
+
+	// Example: forward trace headers from a handler
+	c := httpx.New()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		res, _ := httpx.Get[string](c, "https://httpbin.org/headers", httpx.PropagateFrom(r))
+		_, _ = w.Write([]byte(res))
+	}
+	_ = handler
+}
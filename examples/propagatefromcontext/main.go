@@ -0,0 +1,24 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"net/http"
+)
+
+func main() {
+	// PropagateFromContext forwards the correlation headers stored by
+	// ContextWithPropagation in each request's context. Headers set explicitly on
+	// the request are kept; contexts without stored headers are left alone.
+
+	// Example: forward headers stored in the request context
+	c := httpx.New(httpx.PropagateFromContext())
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ctx := httpx.ContextWithPropagation(r.Context(), r)
+		res, _ := httpx.GetCtx[string](c, ctx, "https://httpbin.org/headers")
+		_, _ = w.Write([]byte(res))
+	}
+	_ = handler
+}
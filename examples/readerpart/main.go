@@ -0,0 +1,22 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"strings"
+)
+
+func main() {
+	// ReaderPart returns a file part read from reader while the request is sent.
+	// The size is what is left to read: it is taken from Seek, or else from Size
+	// or Len, and can be set with WithSize otherwise. Only readers that implement
+	// io.Seeker can be sent again on retries.
+
+	// Example: a streamed part
+	c := httpx.New()
+	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+		httpx.Multipart(httpx.ReaderPart("file", "report.txt", strings.NewReader("hello"))),
+	)
+}
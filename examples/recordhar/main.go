@@ -0,0 +1,20 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// RecordHAR records every attempt made by the client into rec. It enables
+	// tracing so entries carry DNS, connect, TLS and wait timings.
+
+	// Example: compare a browser profile with the real browser
+	rec := httpx.NewHARRecorder()
+	c := httpx.New(httpx.AsFirefox().RecordHAR(rec))
+	_, _ = httpx.Get[string](c, "https://httpbin.org/headers")
+	_, _ = rec.WriteTo(os.Stdout)
+}
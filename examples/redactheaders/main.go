@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"log/slog"
+)
+
+func main() {
+	// RedactHeaders masks the values of the named headers wherever the client logs
+	// or dumps requests and responses. Authorization, Cookie, Set-Cookie, X-Api-Key
+	// and Proxy-Authorization are always masked; names are case-insensitive and
+	// repeated calls add to the list.
+
+	// Example: hide a custom token header from logs
+	c := httpx.New(httpx.
+		Logger(slog.Default(), slog.LevelInfo).
+		RedactHeaders("X-Session-Token"),
+	)
+	_ = c
+}
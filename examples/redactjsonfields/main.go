@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// RedactJSONFields masks string, number, boolean and null values of the named
+	// JSON object fields in dumped and logged bodies, at any nesting depth. Names
+	// are case-insensitive and repeated calls add to the list.
+
+	// Example: hide credentials in dumped JSON bodies
+	var buf bytes.Buffer
+	c := httpx.New(httpx.
+		DumpEachRequestTo(&buf).
+		RedactJSONFields("password", "access_token"),
+	)
+	_ = c
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"log/slog"
+)
+
+func main() {
+	// RedactQuery masks the values of the named query parameters in logged and
+	// dumped URLs. Names are case-insensitive and repeated calls add to the list.
+
+	// Example: hide an API key passed in the query string
+	c := httpx.New(httpx.
+		Logger(slog.Default(), slog.LevelInfo).
+		RedactQuery("api_key", "signature"),
+	)
+	_ = c
+}
@@ -0,0 +1,14 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// Reset discards the recorded entries.
+
+	// Example: start a fresh recording
+	rec := httpx.NewHARRecorder()
+	rec.Reset()
+}
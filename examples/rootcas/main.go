@@ -0,0 +1,20 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// RootCAs trusts only the given PEM-encoded CA certificates when verifying servers.
+	// The system roots are not consulted once RootCAs is set; calling it again adds
+	// to the same pool.
+
+	// Example: trust a private CA
+	caPEM, _ := os.ReadFile("ca.pem")
+	c := httpx.New(httpx.RootCAs(caPEM))
+	_ = c
+}
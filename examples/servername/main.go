@@ -0,0 +1,14 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// ServerName overrides the name used for SNI and certificate verification.
+
+	// Example: connect by IP but verify the service name
+	c := httpx.New(httpx.ServerName("api.internal"))
+	_ = c
+}
@@ -0,0 +1,20 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// SigV4 signs every attempt with AWS Signature Version 4.
+	// Signing happens in the transport, so retries and redirects are re-signed with
+	// a fresh timestamp. The payload is hashed unless SigV4UnsignedPayload or
+	// SigV4StreamingPayload selects a different mode, and session tokens are sent
+	// as X-Amz-Security-Token.
+
+	// Example: sign S3 requests
+	creds := httpx.StaticAWSCredentials("AKID", "SECRET", "")
+	c := httpx.New(httpx.SigV4(creds, "us-east-1", "s3"))
+	res, _ := httpx.Get[string](c, "https://my-bucket.s3.amazonaws.com/report.csv")
+	httpx.Dump(res) // dumps string
+}
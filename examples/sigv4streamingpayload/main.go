@@ -0,0 +1,26 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"io"
+	"os"
+	"strconv"
+)
+
+func main() {
+	// SigV4StreamingPayload signs the body in 64 KiB aws-chunked chunks instead of hashing it up front.
+	// The decoded length comes from the request body or an explicit X-Amz-Decoded-Content-Length header.
+
+	// Example: stream a large upload
+	creds := httpx.StaticAWSCredentials("AKID", "SECRET", "")
+	c := httpx.New(httpx.SigV4(creds, "us-east-1", "s3"))
+	f, _ := os.Open("/tmp/large.bin")
+	info, _ := f.Stat()
+	_, _ = httpx.Put[io.Reader, string](c, "https://my-bucket.s3.amazonaws.com/large.bin", f,
+		httpx.SigV4StreamingPayload().
+			Header("X-Amz-Decoded-Content-Length", strconv.FormatInt(info.Size(), 10)),
+	)
+}
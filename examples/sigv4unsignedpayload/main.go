@@ -0,0 +1,18 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// SigV4UnsignedPayload skips payload hashing and signs the literal UNSIGNED-PAYLOAD.
+	// Use it for large or non-replayable bodies sent over HTTPS to services that accept it (such as S3).
+
+	// Example: upload without hashing the body
+	creds := httpx.StaticAWSCredentials("AKID", "SECRET", "")
+	c := httpx.New(httpx.SigV4(creds, "us-east-1", "s3"))
+	_, _ = httpx.Put[[]byte, string](c, "https://my-bucket.s3.amazonaws.com/blob", []byte("data"),
+		httpx.SigV4UnsignedPayload(),
+	)
+}
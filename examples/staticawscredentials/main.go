@@ -0,0 +1,14 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// StaticAWSCredentials returns a provider that always yields the same credentials.
+
+	// Example: static credentials
+	creds := httpx.StaticAWSCredentials("AKID", "SECRET", "")
+	_ = httpx.New(httpx.SigV4(creds, "us-east-1", "s3"))
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"github.com/imroc/req/v3"
+)
+
+func main() {
+	// TimingHook enables tracing and calls hook with the timing of every attempt,
+	// including retries and attempts that failed before a response arrived.
+
+	// Example: feed latency breakdowns into metrics
+	c := httpx.New(httpx.TimingHook(func(resp *req.Response, timing httpx.Timing) {
+		fmt.Printf("%s ttfb=%s total=%s reused=%v\n", timing.RemoteAddr, timing.TTFB, timing.Total, timing.ConnReused)
+	}))
+	_ = c
+}
@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// TimingOf returns the timing breakdown of the attempt that produced resp.
+
+	// Example: read timings from a raw response
+	c := httpx.New(httpx.TraceAll())
+	_, resp, _ := httpx.Do[string](c.Req().R().SetURL("https://httpbin.org/get"))
+	timing := httpx.TimingOf(resp)
+	fmt.Println(timing.DNS, timing.TLS, timing.TTFB, timing.Total)
+}
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// TusChecksum sends an Upload-Checksum with every chunk of a TusUpload, using
+	// the tus checksum extension. The server rejects a chunk that was corrupted
+	// on the way, and it is sent again. Before the upload starts, an OPTIONS
+	// request checks that the server supports the extension and the algorithm;
+	// if not, TusUpload fails without sending any data.
+
+	// Example: verify each chunk with SHA-256
+	f, _ := os.Open("/tmp/video.mp4")
+	c := httpx.New()
+	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f, httpx.TusChecksum(httpx.DigestSHA256))
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// TusChunkSize sets how many bytes TusUpload sends per PATCH request. Each
+	// chunk is held in memory so it can be sent again after a failure. The
+	// default is 4 MiB.
+
+	// Example: smaller chunks for slow links
+	f, _ := os.Open("/tmp/video.mp4")
+	c := httpx.New()
+	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f, httpx.TusChunkSize(1<<20))
+}
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+	"strings"
+)
+
+func main() {
+	// TusFingerprint identifies the data of a TusUpload for TusResume, such as a
+	// content hash or a database ID. It is required to resume readers that are
+	// not files.
+
+	// Example: resume a generated stream
+	store := httpx.NewTusMemoryStore()
+	c := httpx.New(httpx.TusResume(store))
+	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", strings.NewReader("report"),
+		httpx.TusFingerprint("report-2024-06"),
+	)
+}
@@ -0,0 +1,22 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// TusMetadata adds Upload-Metadata pairs, such as the file name, to the
+	// creation of a TusUpload. Repeated calls merge their pairs.
+
+	// Example: name the upload
+	f, _ := os.Open("/tmp/video.mp4")
+	c := httpx.New()
+	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f,
+		httpx.TusMetadata(map[string]string{"filename": "video.mp4", "filetype": "video/mp4"}),
+	)
+}
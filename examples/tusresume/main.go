@@ -0,0 +1,25 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// TusResume remembers the URL of each TusUpload in store, keyed by the
+	// endpoint and a fingerprint of the upload, so that uploading the same data
+	// again, even from another process, continues where the last attempt
+	// stopped. Files are fingerprinted by path, size and modification time; set
+	// TusFingerprint for other readers. Entries are removed once an upload
+	// completes.
+
+	// Example: survive process restarts
+	store := httpx.NewTusFileStore("/var/lib/myapp/uploads.json")
+	c := httpx.New(httpx.TusResume(store))
+	f, _ := os.Open("/tmp/video.mp4")
+	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f)
+}
@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"github.com/goforj/httpx/v2"
+)
+
+func main() {
+	// TusTerminate deletes an unfinished upload from a tus server that supports
+	// the termination extension. The server frees its storage and later requests
+	// for the upload fail.
+
+	// Example: abandon an upload
+	c := httpx.New()
+	_ = httpx.TusTerminate(context.Background(), c, "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+}
@@ -0,0 +1,35 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// TusUpload uploads everything reader yields to a tus 1.0 server and returns
+	// the URL of the upload. Chunks are sent with PATCH requests, and when one
+	// fails the upload asks the server how much it received and continues from
+	// there. A reader that implements io.Seeker is uploaded from its current
+	// position and its size is announced up front; the length of other readers is
+	// sent once they are drained.
+	//
+	// Request options apply to every request of the upload. UploadCallback,
+	// UploadCallbackWithInterval and UploadProgress report the upload as a whole.
+
+	// Example: upload a large file in 8 MiB chunks
+	f, _ := os.Open("/tmp/video.mp4")
+	defer f.Close()
+	c := httpx.New()
+	uploadURL, _ := httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f,
+		httpx.TusChunkSize(8<<20),
+		httpx.TusMetadata(map[string]string{"filename": "video.mp4"}),
+		httpx.UploadProgress(),
+	)
+	fmt.Println(uploadURL)
+	// https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216
+}
@@ -6,7 +6,9 @@ package main
 import "github.com/goforj/httpx/v2"
 
 func main() {
-	// UploadProgress enables a default progress spinner and bar for uploads.
+	// UploadProgress shows upload progress with the transfer rate and estimated
+	// time left. Output goes to the renderer set with ProgressTo, or else to
+	// stdout, as bars on a terminal and as log lines otherwise.
 
 	// Example: upload with automatic progress
 	c := httpx.New()
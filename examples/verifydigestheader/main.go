@@ -0,0 +1,19 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// VerifyDigestHeader verifies files written by OutputFile and Download against
+	// the RFC 9530 Repr-Digest response header, or Content-Digest when the whole
+	// file arrived in one plain response, and implies AtomicDownload. A mismatch
+	// is reported as a *ChecksumError; a response without a usable sha-256 or
+	// sha-512 digest fails the download.
+
+	// Example: trust the server's digest
+	c := httpx.New(httpx.VerifyDigestHeader())
+	_, err := httpx.Get[[]byte](c, "https://example.com/data.bin", httpx.OutputFile("/tmp/data.bin"))
+	_ = err
+}
@@ -0,0 +1,31 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"net/http"
+	"net/http/httptest"
+)
+
+func main() {
+	// VerifyRequestSignature verifies the RFC 9421 signature created with keyID on an incoming request.
+	// Covered Content-Digest headers are checked against the body, which is restored for later reads.
+	// The key is the public key (or HMAC secret) matching alg.
+
+	// Example: verify in a test server
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := httpx.VerifyRequestSignature(r, "partner-key", httpx.SignatureEd25519, pub); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	c := httpx.New(httpx.MessageSignature("partner-key", httpx.SignatureEd25519, priv))
+	_, err := httpx.Get[string](c, srv.URL)
+	fmt.Println(err == nil)
+	// true
+}
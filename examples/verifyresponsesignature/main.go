@@ -0,0 +1,27 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"github.com/goforj/httpx/v2"
+	"net/http"
+)
+
+func main() {
+	// VerifyResponseSignature verifies the RFC 9421 signature created with keyID on a response.
+	// Components marked with ;req are resolved against resp.Request. Covered
+	// Content-Digest headers are checked against the body, which is restored for later reads.
+
+	// Example: verify a signed response
+	pub, _, _ := ed25519.GenerateKey(nil)
+	r := httpx.New().Req().R().SetURL("https://api.example.com/payments/1")
+	r.Method = http.MethodGet
+	_, resp, err := httpx.Do[string](r)
+	if err != nil {
+		return
+	}
+	fmt.Println(httpx.VerifyResponseSignature(resp.Response, "server-key", httpx.SignatureEd25519, pub))
+}
@@ -0,0 +1,15 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// WithContentType sets the Content-Type of the part. File parts default to
+	// application/octet-stream.
+
+	// Example: a JSON part
+	part := httpx.FieldPart("meta", `{"title":"report"}`).WithContentType("application/json")
+	_ = part
+}
@@ -0,0 +1,15 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/goforj/httpx/v2"
+
+func main() {
+	// WithHeader sets a header of the part, such as Content-ID for
+	// MultipartRelated.
+
+	// Example: reference a part by ID
+	part := httpx.FilePart("logo", "/tmp/logo.png").WithHeader("Content-ID", "<logo>")
+	_ = part
+}
@@ -0,0 +1,23 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"io"
+)
+
+func main() {
+	// WithSize declares the size of a part whose reader cannot report it, so
+	// that the request can carry a Content-Length.
+
+	// Example: a pipe of known length
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("hello"))
+		_ = pw.Close()
+	}()
+	part := httpx.ReaderPart("file", "report.txt", pr).WithSize(5)
+	_ = part
+}
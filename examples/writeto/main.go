@@ -0,0 +1,17 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"github.com/goforj/httpx/v2"
+	"os"
+)
+
+func main() {
+	// WriteTo writes the recorded entries as a HAR 1.2 JSON document.
+
+	// Example: write the recording to stdout
+	rec := httpx.NewHARRecorder()
+	_, _ = rec.WriteTo(os.Stdout)
+}
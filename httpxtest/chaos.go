@@ -0,0 +1,374 @@
+package httpxtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fault names a kind of failure injected by Chaos.
+type Fault string
+
+const (
+	FaultLatency  Fault = "latency"
+	FaultReset    Fault = "reset"
+	FaultDNS      Fault = "dns"
+	FaultStatus   Fault = "status"
+	FaultTruncate Fault = "truncate"
+	FaultTrickle  Fault = "trickle"
+)
+
+// Distribution draws a latency from r.
+type Distribution func(r *rand.Rand) time.Duration
+
+// Fixed always returns d.
+func Fixed(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// Uniform returns latencies spread evenly between min and max.
+func Uniform(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int64N(int64(max-min)))
+	}
+}
+
+// Normal returns normally distributed latencies, clamped at zero.
+func Normal(mean, stddev time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return max(0, mean+time.Duration(r.NormFloat64()*float64(stddev)))
+	}
+}
+
+// Exponential returns exponentially distributed latencies with the given
+// mean, which gives the long tail typical of real services.
+func Exponential(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// Chaos is an http.RoundTripper that injects faults into requests before
+// passing them to the next transport. Faults are configured per route and
+// drawn from a seeded generator, so a sequence of requests fails the same
+// way on every run. Install it with httpx.Transport.
+//
+// Example:
+//
+//	chaos := httpxtest.NewChaos(nil, 42)
+//	chaos.Route("GET", "/users/{id}").Status(0.5, http.StatusServiceUnavailable)
+//	chaos.All().Latency(1, httpxtest.Uniform(10*time.Millisecond, 50*time.Millisecond))
+//	c := httpx.New(httpx.Transport(chaos))
+type Chaos struct {
+	mu     sync.Mutex
+	next   http.RoundTripper
+	rng    *rand.Rand
+	rules  []*ChaosRule
+	counts map[Fault]int
+}
+
+// NewChaos wraps next, or http.DefaultTransport when next is nil, with a
+// fault injector seeded with seed.
+func NewChaos(next http.RoundTripper, seed uint64) *Chaos {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Chaos{
+		next:   next,
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+		counts: map[Fault]int{},
+	}
+}
+
+// Route adds fault rules for requests matching method and path. An empty
+// method matches any method, and path uses the same {name} and {name...}
+// patterns as Server.Expect. Requests use the first route that matches.
+func (c *Chaos) Route(method, path string) *ChaosRule {
+	rule := &ChaosRule{method: strings.ToUpper(method), pattern: path}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, rule)
+	return rule
+}
+
+// All adds fault rules for every request not matched by an earlier route.
+func (c *Chaos) All() *ChaosRule {
+	return c.Route("", "/{path...}")
+}
+
+// Count reports how many times fault has been injected.
+func (c *Chaos) Count(fault Fault) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[fault]
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *Chaos) RoundTrip(req *http.Request) (*http.Response, error) {
+	plan := c.plan(req)
+	if plan.latency > 0 {
+		if err := sleep(req.Context(), plan.latency); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+	switch {
+	case plan.dns:
+		closeRequestBody(req)
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: req.URL.Hostname(), IsNotFound: true}}
+	case plan.reset:
+		closeRequestBody(req)
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	case plan.status != 0:
+		closeRequestBody(req)
+		body := fmt.Sprintf("httpxtest: injected %d", plan.status)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", plan.status, http.StatusText(plan.status)),
+			StatusCode:    plan.status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	if plan.truncate >= 0 {
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: plan.truncate}
+	}
+	if plan.trickle != nil {
+		resp.Body = &trickleBody{ReadCloser: resp.Body, ctx: req.Context(), chunk: plan.trickle.chunk, every: plan.trickle.every}
+	}
+	return resp, nil
+}
+
+// closeRequestBody closes the body of a request that is answered without
+// reaching the wrapped transport, as http.RoundTripper requires.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// chaosPlan is the set of faults drawn for one request.
+type chaosPlan struct {
+	latency  time.Duration
+	dns      bool
+	reset    bool
+	status   int
+	truncate int64
+	trickle  *trickle
+}
+
+func (c *Chaos) plan(req *http.Request) chaosPlan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	plan := chaosPlan{truncate: -1}
+	var rule *ChaosRule
+	for _, r := range c.rules {
+		if r.matches(req) {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		return plan
+	}
+	// Every configured fault is rolled, and the latency drawn, on every
+	// request so the random sequence, and so later outcomes, does not depend
+	// on which faults fired.
+	if rule.latency != nil {
+		d := rule.latency.dist(c.rng)
+		if c.roll(rule.latency.rate) {
+			plan.latency = d
+			c.counts[FaultLatency]++
+		}
+	}
+	if c.roll(rule.dnsRate) && !plan.failed() {
+		plan.dns = true
+		c.counts[FaultDNS]++
+	}
+	if c.roll(rule.resetRate) && !plan.failed() {
+		plan.reset = true
+		c.counts[FaultReset]++
+	}
+	if rule.status != nil && c.roll(rule.status.rate) && !plan.failed() {
+		plan.status = rule.status.code
+		c.counts[FaultStatus]++
+	}
+	if rule.truncate != nil && c.roll(rule.truncate.rate) && !plan.failed() {
+		plan.truncate = rule.truncate.after
+		c.counts[FaultTruncate]++
+	}
+	if rule.trickle != nil && c.roll(rule.trickle.rate) && !plan.failed() {
+		plan.trickle = rule.trickle
+		c.counts[FaultTrickle]++
+	}
+	return plan
+}
+
+func (p chaosPlan) failed() bool {
+	return p.dns || p.reset || p.status != 0
+}
+
+func (c *Chaos) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	return c.rng.Float64() < rate
+}
+
+// ChaosRule holds the faults injected for one route. Each fault fires
+// independently with its rate, between 0 and 1. Its methods return the rule
+// so calls can be chained.
+type ChaosRule struct {
+	method    string
+	pattern   string
+	latency   *latency
+	dnsRate   float64
+	resetRate float64
+	status    *statusFault
+	truncate  *truncation
+	trickle   *trickle
+}
+
+type latency struct {
+	rate float64
+	dist Distribution
+}
+
+type statusFault struct {
+	rate float64
+	code int
+}
+
+type truncation struct {
+	rate  float64
+	after int64
+}
+
+type trickle struct {
+	rate  float64
+	chunk int
+	every time.Duration
+}
+
+// Latency delays requests by a duration drawn from dist.
+func (r *ChaosRule) Latency(rate float64, dist Distribution) *ChaosRule {
+	r.latency = &latency{rate: rate, dist: dist}
+	return r
+}
+
+// DNSFailure fails requests with a *net.DNSError before they are sent.
+func (r *ChaosRule) DNSFailure(rate float64) *ChaosRule {
+	r.dnsRate = rate
+	return r
+}
+
+// Reset fails requests with a connection reset (syscall.ECONNRESET).
+func (r *ChaosRule) Reset(rate float64) *ChaosRule {
+	r.resetRate = rate
+	return r
+}
+
+// Status answers requests with code without sending them.
+func (r *ChaosRule) Status(rate float64, code int) *ChaosRule {
+	r.status = &statusFault{rate: rate, code: code}
+	return r
+}
+
+// Truncate cuts response bodies after n bytes with io.ErrUnexpectedEOF.
+// Bodies of n bytes or fewer end normally.
+func (r *ChaosRule) Truncate(rate float64, n int64) *ChaosRule {
+	r.truncate = &truncation{rate: rate, after: max(0, n)}
+	return r
+}
+
+// Trickle delivers response bodies chunk bytes at a time, waiting every
+// between chunks.
+func (r *ChaosRule) Trickle(rate float64, chunk int, every time.Duration) *ChaosRule {
+	r.trickle = &trickle{rate: rate, chunk: max(1, chunk), every: every}
+	return r
+}
+
+func (r *ChaosRule) matches(req *http.Request) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	_, ok := matchPath(r.pattern, req.URL.Path)
+	return ok
+}
+
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// A body that ends exactly at the cut was not truncated.
+		var probe [1]byte
+		if _, err := io.ReadFull(b.ReadCloser, probe[:]); err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+type trickleBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	chunk   int
+	every   time.Duration
+	started bool
+}
+
+func (b *trickleBody) Read(p []byte) (int, error) {
+	if b.started {
+		if err := sleep(b.ctx, b.every); err != nil {
+			return 0, err
+		}
+	}
+	b.started = true
+	if len(p) > b.chunk {
+		p = p[:b.chunk]
+	}
+	return b.ReadCloser.Read(p)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
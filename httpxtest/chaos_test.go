@@ -0,0 +1,200 @@
+package httpxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/goforj/httpx/v2"
+	"github.com/imroc/req/v3"
+)
+
+func newChaosServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 64)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func chaosOutcomes(t *testing.T, seed uint64, url string) []string {
+	t.Helper()
+	chaos := NewChaos(nil, seed)
+	chaos.Route("GET", "/flaky").Status(0.3, http.StatusServiceUnavailable).Reset(0.2)
+	c := httpx.New(httpx.Transport(chaos))
+	var out []string
+	for range 20 {
+		_, err := httpx.Get[string](c, url+"/flaky")
+		var httpErr *httpx.HTTPError
+		switch {
+		case err == nil:
+			out = append(out, "ok")
+		case errors.As(err, &httpErr):
+			out = append(out, httpErr.Status)
+		case errors.Is(err, syscall.ECONNRESET):
+			out = append(out, "reset")
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return out
+}
+
+func TestChaosDeterministic(t *testing.T) {
+	srv := newChaosServer(t)
+	first := chaosOutcomes(t, 7, srv.URL)
+	if again := chaosOutcomes(t, 7, srv.URL); strings.Join(again, ",") != strings.Join(first, ",") {
+		t.Fatalf("same seed gave different outcomes:\n%v\n%v", first, again)
+	}
+	joined := strings.Join(first, ",")
+	for _, want := range []string{"ok", "503 Service Unavailable", "reset"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("outcomes %v missing %s", first, want)
+		}
+	}
+}
+
+func TestChaosOutcomesIndependentOfLatency(t *testing.T) {
+	statuses := func(latencyRate float64) []int {
+		chaos := NewChaos(nil, 11)
+		chaos.All().Latency(latencyRate, Normal(time.Millisecond, time.Millisecond)).Status(0.5, http.StatusBadGateway)
+		var out []int
+		for range 50 {
+			out = append(out, chaos.plan(httptest.NewRequest(http.MethodGet, "/any", nil)).status)
+		}
+		return out
+	}
+	first, second := statuses(0.2), statuses(0.9)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("status outcomes depend on the latency rate:\n%v\n%v", first, second)
+		}
+	}
+}
+
+func TestChaosRetriesRecover(t *testing.T) {
+	srv := newChaosServer(t)
+	chaos := NewChaos(nil, 1)
+	chaos.All().Status(0.5, http.StatusBadGateway)
+	c := httpx.New(httpx.Transport(chaos), httpx.RetryCount(10).RetryCondition(func(resp *req.Response, err error) bool {
+		return err != nil || resp.StatusCode >= 500
+	}))
+	for range 5 {
+		if _, err := httpx.Get[string](c, srv.URL+"/any"); err != nil {
+			t.Fatalf("retries did not recover: %v", err)
+		}
+	}
+	if chaos.Count(FaultStatus) == 0 {
+		t.Fatalf("no faults injected")
+	}
+}
+
+func TestChaosFaults(t *testing.T) {
+	srv := newChaosServer(t)
+
+	chaos := NewChaos(nil, 3)
+	chaos.Route("", "/dns").DNSFailure(1)
+	chaos.Route("", "/truncate").Truncate(1, 10)
+	chaos.Route("", "/trickle").Trickle(1, 16, 20*time.Millisecond)
+	chaos.Route("", "/slow").Latency(1, Fixed(time.Second))
+	c := httpx.New(httpx.Transport(chaos))
+
+	_, err := httpx.Get[string](c, srv.URL+"/dns")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Fatalf("expected DNS error, got %v", err)
+	}
+
+	_, err = httpx.Get[string](c, srv.URL+"/truncate")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected truncated body, got %v", err)
+	}
+
+	start := time.Now()
+	body, err := httpx.Get[string](c, srv.URL+"/trickle")
+	if err != nil || len(body) != 64 {
+		t.Fatalf("trickle = %d bytes, %v", len(body), err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("trickle finished in %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := httpx.GetCtx[string](c, ctx, srv.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected latency to honor the deadline, got %v", err)
+	}
+
+	body, err = httpx.Get[string](c, srv.URL+"/untouched")
+	if err != nil || len(body) != 64 {
+		t.Fatalf("unmatched route = %d bytes, %v", len(body), err)
+	}
+	for _, fault := range []Fault{FaultDNS, FaultTruncate, FaultTrickle, FaultLatency} {
+		if chaos.Count(fault) != 1 {
+			t.Fatalf("%s injected %d times", fault, chaos.Count(fault))
+		}
+	}
+}
+
+func TestChaosTruncateAtBodyLength(t *testing.T) {
+	srv := newChaosServer(t)
+	chaos := NewChaos(nil, 1)
+	chaos.All().Truncate(1, 64)
+	body, err := httpx.Get[string](httpx.New(httpx.Transport(chaos)), srv.URL)
+	if err != nil || len(body) != 64 {
+		t.Fatalf("body = %d bytes, %v", len(body), err)
+	}
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTracker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestChaosClosesRequestBodies(t *testing.T) {
+	chaos := NewChaos(nil, 1)
+	chaos.Route("", "/dns").DNSFailure(1)
+	chaos.Route("", "/reset").Reset(1)
+	chaos.Route("", "/status").Status(1, http.StatusBadGateway)
+	for _, path := range []string{"/dns", "/reset", "/status"} {
+		body := &closeTracker{Reader: strings.NewReader("payload")}
+		r, err := http.NewRequest(http.MethodPost, "http://chaos.test"+path, body)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		if resp, err := chaos.RoundTrip(r); err == nil {
+			_ = resp.Body.Close()
+		}
+		if !body.closed {
+			t.Fatalf("%s: request body was not closed", path)
+		}
+	}
+}
+
+func TestDistributions(t *testing.T) {
+	r := NewChaos(nil, 9).rng
+	for range 100 {
+		if d := Uniform(10*time.Millisecond, 20*time.Millisecond)(r); d < 10*time.Millisecond || d >= 20*time.Millisecond {
+			t.Fatalf("uniform out of range: %s", d)
+		}
+		if d := Normal(time.Millisecond, 10*time.Millisecond)(r); d < 0 {
+			t.Fatalf("normal below zero: %s", d)
+		}
+		if d := Exponential(time.Millisecond)(r); d < 0 {
+			t.Fatalf("exponential below zero: %s", d)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Package httpxtest provides helpers for testing code built on httpx.
+//
+// Recorder captures real HTTP interactions into a cassette file and replays
+// them offline, so tests against third-party APIs stay deterministic:
+//
+//	rec, err := httpxtest.NewRecorder("testdata/github.yaml", httpxtest.ModeRecordMissing)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	c := httpx.New(httpx.Transport(rec))
+//
+// Server is a mock server driven by expectations, verified at t.Cleanup:
+//
+//	srv := httpxtest.NewServer(t)
+//	srv.Expect("GET", "/users/{id}").WithHeader("X-Tenant", "acme").Once().
+//		RespondJSON(http.StatusOK, user)
+//	c := srv.Client(httpx.Header("X-Tenant", "acme"))
+//
+// Chaos wraps a transport and injects latency, resets, DNS failures, status
+// codes, truncated and trickled bodies per route, from a fixed seed.
+package httpxtest
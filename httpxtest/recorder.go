@@ -0,0 +1,474 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode controls whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeRecord sends every request to the real transport and rewrites the
+	// cassette from scratch.
+	ModeRecord Mode = iota
+	// ModeReplay serves requests from the cassette only and fails requests
+	// that have no recorded interaction.
+	ModeReplay
+	// ModeRecordMissing replays recorded interactions and records the ones
+	// that are missing.
+	ModeRecordMissing
+)
+
+// String returns the mode name.
+func (m Mode) String() string {
+	switch m {
+	case ModeRecord:
+		return "record"
+	case ModeReplay:
+		return "replay"
+	case ModeRecordMissing:
+		return "record-missing"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// ErrInteractionNotFound is returned in replay mode when no recorded
+// interaction matches a request.
+var ErrInteractionNotFound = errors.New("httpxtest: no recorded interaction matches request")
+
+const redactedValue = "REDACTED"
+
+var defaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization"}
+
+// Cassette is the on-disk form of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a recorded request and the response it produced.
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method       string      `json:"method" yaml:"method"`
+	URL          string      `json:"url" yaml:"url"`
+	Headers      http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status       int         `json:"status" yaml:"status"`
+	Headers      http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Matcher reports whether a live request matches a recorded one. The live
+// request has already been through the recorder's redaction, so both sides
+// compare in the form they are stored in.
+type Matcher func(live, recorded Request) bool
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(live, recorded Request) bool {
+	return live.Method == recorded.Method
+}
+
+// MatchURL matches requests with the same URL.
+func MatchURL(live, recorded Request) bool {
+	return live.URL == recorded.URL
+}
+
+// MatchBody matches requests with the same body.
+func MatchBody(live, recorded Request) bool {
+	return live.Body == recorded.Body && live.BodyEncoding == recorded.BodyEncoding
+}
+
+// MatchHeaders returns a matcher comparing the named headers.
+func MatchHeaders(names ...string) Matcher {
+	return func(live, recorded Request) bool {
+		for _, name := range names {
+			if strings.Join(live.Headers.Values(name), ",") != strings.Join(recorded.Headers.Values(name), ",") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithMatchers replaces the default method and URL matchers. A recorded
+// interaction is used only when every matcher accepts it.
+func WithMatchers(matchers ...Matcher) RecorderOption {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+// WithRealTransport sets the transport used to record interactions.
+// Defaults to http.DefaultTransport.
+func WithRealTransport(rt http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		if rt != nil {
+			r.real = rt
+		}
+	}
+}
+
+// RedactHeaders masks the named headers, in addition to Authorization,
+// Cookie, Set-Cookie, X-Api-Key and Proxy-Authorization, before they are
+// saved.
+func RedactHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactHeaders = append(r.redactHeaders, names...)
+	}
+}
+
+// RedactQuery masks the named query parameters in recorded URLs.
+func RedactQuery(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactQuery = append(r.redactQuery, names...)
+	}
+}
+
+// RedactWith runs fn on every interaction before it is saved, after the
+// built-in header and query redaction. Use it to scrub bodies. Live requests
+// go through fn as well before they are matched, with an empty Response.
+func RedactWith(fn func(*Interaction)) RecorderOption {
+	return func(r *Recorder) {
+		if fn != nil {
+			r.redactFuncs = append(r.redactFuncs, fn)
+		}
+	}
+}
+
+// Recorder is an http.RoundTripper that records interactions to a cassette
+// file and replays them. Install it with httpx.Transport.
+//
+// Recorded interactions are replayed in order: each request takes the first
+// matching interaction that has not been used yet, and once all matches are
+// used the last one is reused. The cassette format follows the file
+// extension: .yaml and .yml are YAML, anything else is JSON.
+type Recorder struct {
+	mu            sync.Mutex
+	path          string
+	mode          Mode
+	real          http.RoundTripper
+	matchers      []Matcher
+	redactHeaders []string
+	redactQuery   []string
+	redactFuncs   []func(*Interaction)
+	cassette      Cassette
+	used          []bool
+}
+
+// NewRecorder opens the cassette at path. In ModeRecord the cassette starts
+// empty; in the other modes it is loaded, and ModeReplay fails if it does
+// not exist.
+func NewRecorder(path string, mode Mode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:          path,
+		mode:          mode,
+		real:          http.DefaultTransport,
+		matchers:      []Matcher{MatchMethod, MatchURL},
+		redactHeaders: defaultRedactedHeaders,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if mode != ModeRecord {
+		if err := r.load(); err != nil && (mode == ModeReplay || !errors.Is(err, os.ErrNotExist)) {
+			return nil, err
+		}
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Interactions returns a copy of the interactions in the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	live := r.request(req, body)
+
+	if r.mode != ModeRecord {
+		if in, ok := r.match(r.redactRequest(live)); ok {
+			return in.Response.httpResponse(req)
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, live.Method, live.URL)
+		}
+	}
+
+	resp, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	in := Interaction{Request: live, Response: r.response(resp, respBody)}
+	for _, fn := range r.redactFuncs {
+		fn(&in)
+	}
+	if err := r.add(in); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// redactRequest runs the RedactWith functions on a copy of live, so that it
+// compares equal to a recorded request that went through them.
+func (r *Recorder) redactRequest(live Request) Request {
+	if len(r.redactFuncs) == 0 {
+		return live
+	}
+	in := Interaction{Request: live}
+	in.Request.Headers = live.Headers.Clone()
+	for _, fn := range r.redactFuncs {
+		fn(&in)
+	}
+	return in.Request
+}
+
+func (r *Recorder) match(live Request) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, in := range r.cassette.Interactions {
+		if !r.matches(live, in.Request) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return in, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return r.cassette.Interactions[last], true
+}
+
+func (r *Recorder) matches(live, recorded Request) bool {
+	for _, m := range r.matchers {
+		if !m(live, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) add(in Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.used = append(r.used, true)
+	return r.save()
+}
+
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	out := Request{
+		Method:  req.Method,
+		URL:     r.url(req.URL),
+		Headers: r.header(req.Header),
+	}
+	out.Body, out.BodyEncoding = encodeBody(body)
+	return out
+}
+
+func (r *Recorder) response(resp *http.Response, body []byte) Response {
+	out := Response{
+		Status:  resp.StatusCode,
+		Headers: r.header(resp.Header),
+	}
+	out.Body, out.BodyEncoding = encodeBody(body)
+	return out
+}
+
+func (r *Recorder) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		// req keeps header ordering under internal keys; they are not sent.
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if r.redactsHeader(name) {
+			out[name] = []string{redactedValue}
+			continue
+		}
+		out[name] = append([]string(nil), values...)
+	}
+	return out
+}
+
+func (r *Recorder) redactsHeader(name string) bool {
+	for _, h := range r.redactHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) url(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if len(r.redactQuery) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+	parts := strings.Split(u.RawQuery, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil {
+			key = name
+		}
+		for _, q := range r.redactQuery {
+			if strings.EqualFold(q, key) {
+				parts[i] = url.QueryEscape(key) + "=" + redactedValue
+				break
+			}
+		}
+	}
+	masked := *u
+	masked.RawQuery = strings.Join(parts, "&")
+	return masked.String()
+}
+
+func (r *Recorder) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("httpxtest: load cassette: %w", err)
+	}
+	if isYAML(r.path) {
+		err = yaml.Unmarshal(data, &r.cassette)
+	} else {
+		err = json.Unmarshal(data, &r.cassette)
+	}
+	if err != nil {
+		return fmt.Errorf("httpxtest: decode cassette %s: %w", r.path, err)
+	}
+	return nil
+}
+
+func (r *Recorder) save() error {
+	var (
+		data []byte
+		err  error
+	)
+	if isYAML(r.path) {
+		data, err = yaml.Marshal(&r.cassette)
+	} else {
+		data, err = json.MarshalIndent(&r.cassette, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("httpxtest: encode cassette: %w", err)
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("httpxtest: save cassette: %w", err)
+		}
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("httpxtest: save cassette: %w", err)
+	}
+	return nil
+}
+
+func (resp Response) httpResponse(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(resp.Body, resp.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("httpxtest: read body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("httpxtest: decode body: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("httpxtest: unknown body encoding %q", encoding)
+	}
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,195 @@
+package httpxtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goforj/httpx/v2"
+)
+
+func newCassetteServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set("Set-Cookie", "session=server-secret")
+		if r.URL.Path == "/binary" {
+			_, _ = w.Write([]byte{0xff, 0xfe, byte(n)})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `","hit":` + string(rune('0'+n)) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	for _, name := range []string{"cassette.yaml", "cassette.json"} {
+		t.Run(name, func(t *testing.T) {
+			srv, hits := newCassetteServer(t)
+			path := filepath.Join(t.TempDir(), "nested", name)
+
+			rec, err := NewRecorder(path, ModeRecord, RedactQuery("key"), RedactHeaders("X-Token"))
+			if err != nil {
+				t.Fatalf("new recorder: %v", err)
+			}
+			c := httpx.New(httpx.Transport(rec), httpx.Header("X-Token", "header-secret"))
+			first, err := httpx.Get[map[string]any](c, srv.URL+"/users?key=query-secret", httpx.Header("Authorization", "Bearer auth-secret"))
+			if err != nil {
+				t.Fatalf("record: %v", err)
+			}
+			if _, err := httpx.Get[[]byte](c, srv.URL+"/binary"); err != nil {
+				t.Fatalf("record binary: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read cassette: %v", err)
+			}
+			for _, secret := range []string{"query-secret", "header-secret", "auth-secret", "server-secret"} {
+				if strings.Contains(string(data), secret) {
+					t.Fatalf("%s saved to cassette:\n%s", secret, data)
+				}
+			}
+
+			srv.Close()
+			replay, err := NewRecorder(path, ModeReplay, RedactQuery("key"))
+			if err != nil {
+				t.Fatalf("open replay: %v", err)
+			}
+			c = httpx.New(httpx.Transport(replay))
+			got, err := httpx.Get[map[string]any](c, srv.URL+"/users?key=other-secret")
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if got["path"] != first["path"] || got["hit"] != first["hit"] {
+				t.Fatalf("replayed %v, recorded %v", got, first)
+			}
+			bin, err := httpx.Get[[]byte](c, srv.URL+"/binary")
+			if err != nil || string(bin) != "\xff\xfe\x02" {
+				t.Fatalf("replay binary = %q, %v", bin, err)
+			}
+			if hits.Load() != 2 {
+				t.Fatalf("server hits = %d", hits.Load())
+			}
+
+			_, err = httpx.Get[string](c, srv.URL+"/missing")
+			if !errors.Is(err, ErrInteractionNotFound) {
+				t.Fatalf("expected ErrInteractionNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRecorderRecordMissing(t *testing.T) {
+	srv, hits := newCassetteServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, _ := NewRecorder(path, ModeRecord)
+	c := httpx.New(httpx.Transport(rec))
+	for _, p := range []string{"/a", "/b", "/a"} {
+		if _, err := httpx.Get[string](c, srv.URL+p); err != nil {
+			t.Fatalf("record %s: %v", p, err)
+		}
+	}
+
+	for range 2 {
+		rec, err := NewRecorder(path, ModeRecordMissing)
+		if err != nil {
+			t.Fatalf("new recorder: %v", err)
+		}
+		c := httpx.New(httpx.Transport(rec))
+		for _, p := range []string{"/a", "/c"} {
+			if _, err := httpx.Get[string](c, srv.URL+p); err != nil {
+				t.Fatalf("get %s: %v", p, err)
+			}
+		}
+		if n := len(rec.Interactions()); n != 4 {
+			t.Fatalf("interactions = %d", n)
+		}
+	}
+	if hits.Load() != 4 {
+		t.Fatalf("server hits = %d, want 4", hits.Load())
+	}
+
+	rec, _ = NewRecorder(path, ModeReplay)
+	c = httpx.New(httpx.Transport(rec))
+	var bodies []string
+	for range 3 {
+		body, err := httpx.Get[string](c, srv.URL+"/a")
+		if err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		bodies = append(bodies, body)
+	}
+	if bodies[0] == bodies[1] || bodies[2] != bodies[1] {
+		t.Fatalf("replay order = %v", bodies)
+	}
+}
+
+func TestRecorderMatchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	if _, err := NewRecorder(path, ModeReplay); err == nil {
+		t.Fatalf("expected error for missing cassette in replay mode")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Tenant") + ":" + r.Method))
+	}))
+	defer srv.Close()
+
+	matchers := WithMatchers(MatchMethod, MatchURL, MatchBody, MatchHeaders("X-Tenant"))
+	rec, _ := NewRecorder(path, ModeRecord, matchers)
+	c := httpx.New(httpx.Transport(rec))
+	for _, tenant := range []string{"a", "b"} {
+		if _, err := httpx.Post[map[string]string, string](c, srv.URL, map[string]string{"tenant": tenant}, httpx.Header("X-Tenant", tenant)); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	rec, _ = NewRecorder(path, ModeReplay, matchers)
+	c = httpx.New(httpx.Transport(rec))
+	got, err := httpx.Post[map[string]string, string](c, srv.URL, map[string]string{"tenant": "b"}, httpx.Header("X-Tenant", "b"))
+	if err != nil || got != "b:POST" {
+		t.Fatalf("replay = %q, %v", got, err)
+	}
+	_, err = httpx.Post[map[string]string, string](c, srv.URL, map[string]string{"tenant": "b"}, httpx.Header("X-Tenant", "a"))
+	if !errors.Is(err, ErrInteractionNotFound) {
+		t.Fatalf("expected header mismatch, got %v", err)
+	}
+}
+
+func TestRecorderRedactWithMatchBody(t *testing.T) {
+	srv, hits := newCassetteServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	scrub := RedactWith(func(in *Interaction) {
+		in.Request.Body = strings.ReplaceAll(in.Request.Body, "body-secret", "REDACTED")
+	})
+	matchers := WithMatchers(MatchMethod, MatchURL, MatchBody)
+
+	rec, _ := NewRecorder(path, ModeRecord, scrub, matchers)
+	c := httpx.New(httpx.Transport(rec))
+	if _, err := httpx.Post[map[string]string, string](c, srv.URL+"/login", map[string]string{"password": "body-secret"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "body-secret") {
+		t.Fatalf("body secret saved to cassette:\n%s", data)
+	}
+
+	rec, _ = NewRecorder(path, ModeReplay, scrub, matchers)
+	c = httpx.New(httpx.Transport(rec))
+	if _, err := httpx.Post[map[string]string, string](c, srv.URL+"/login", map[string]string{"password": "body-secret"}); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("server hits = %d", hits.Load())
+	}
+}
@@ -0,0 +1,344 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goforj/httpx/v2"
+)
+
+// Server is a mock HTTP server driven by expectations. Requests that match no
+// expectation get a 501 response, and both unexpected requests and unmet
+// expectations fail the test when it is cleaned up.
+type Server struct {
+	*httptest.Server
+
+	t          testing.TB
+	mu         sync.Mutex
+	ordered    bool
+	next       int
+	expected   []*Expectation
+	unexpected []string
+}
+
+// NewServer starts a mock server that is closed and verified at t.Cleanup.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(func() {
+		s.Close()
+		s.Verify()
+	})
+	return s
+}
+
+// InOrder requires expectations to be met in the order they were declared.
+// An expectation may be passed over once it has reached its minimum call
+// count.
+func (s *Server) InOrder() *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ordered = true
+	return s
+}
+
+// Expect declares an expected request. The path may contain {name}
+// segments, available to handlers via r.PathValue, and may end in a
+// {name...} segment matching the rest of the path. The expectation is met
+// by exactly one call unless Times or AnyTimes says otherwise.
+func (s *Server) Expect(method, path string) *Expectation {
+	e := &Expectation{
+		method:  strings.ToUpper(method),
+		pattern: path,
+		min:     1,
+		max:     1,
+		status:  http.StatusOK,
+		header:  http.Header{},
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expected = append(s.expected, e)
+	return e
+}
+
+// Client returns a client whose base URL points at the server.
+func (s *Server) Client(opts ...httpx.Option) *httpx.Client {
+	return httpx.New(append([]httpx.Option{httpx.BaseURL(s.URL)}, opts...)...)
+}
+
+// Verify fails the test for unexpected requests and for expectations called
+// fewer times than required. It runs automatically at t.Cleanup.
+func (s *Server) Verify() {
+	s.t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, req := range s.unexpected {
+		s.t.Errorf("httpxtest: unexpected request %s", req)
+	}
+	s.unexpected = nil
+	for _, e := range s.expected {
+		if e.calls < e.min {
+			s.t.Errorf("httpxtest: expected %s to be called %s, got %d", e, e.times(), e.calls)
+		}
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	e, params, reason := s.match(r, body)
+	if e == nil {
+		s.mu.Lock()
+		s.unexpected = append(s.unexpected, reason)
+		s.mu.Unlock()
+		http.Error(w, "httpxtest: "+reason, http.StatusNotImplemented)
+		return
+	}
+	for name, value := range params {
+		r.SetPathValue(name, value)
+	}
+	e.respond(w, r)
+}
+
+func (s *Server) match(r *http.Request, body []byte) (*Expectation, map[string]string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	desc := r.Method + " " + r.URL.RequestURI()
+	if s.ordered {
+		for ; s.next < len(s.expected); s.next++ {
+			e := s.expected[s.next]
+			if params, ok := e.matches(r, body); ok && e.calls < e.max {
+				e.calls++
+				return e, params, ""
+			}
+			if e.calls < e.min {
+				return nil, nil, fmt.Sprintf("%s (next expected %s)", desc, e)
+			}
+		}
+		return nil, nil, desc + " (all expectations met)"
+	}
+	saturated := false
+	for _, e := range s.expected {
+		params, ok := e.matches(r, body)
+		if !ok {
+			continue
+		}
+		if e.calls < e.max {
+			e.calls++
+			return e, params, ""
+		}
+		saturated = true
+	}
+	if saturated {
+		return nil, nil, desc + " (called more times than expected)"
+	}
+	return nil, nil, desc
+}
+
+// Expectation describes an expected request and the response to send.
+// Its methods return the expectation so calls can be chained.
+type Expectation struct {
+	method  string
+	pattern string
+	headers [][2]string
+	query   [][2]string
+	body    func([]byte) bool
+	min     int
+	max     int
+	calls   int
+
+	status  int
+	header  http.Header
+	payload []byte
+	handler http.HandlerFunc
+}
+
+const unlimited = int(^uint(0) >> 1)
+
+// String returns the method and path pattern.
+func (e *Expectation) String() string {
+	return e.method + " " + e.pattern
+}
+
+// WithHeader requires the request to carry a header with the given value.
+func (e *Expectation) WithHeader(name, value string) *Expectation {
+	e.headers = append(e.headers, [2]string{name, value})
+	return e
+}
+
+// WithQuery requires the request to carry a query parameter with the given
+// value.
+func (e *Expectation) WithQuery(name, value string) *Expectation {
+	e.query = append(e.query, [2]string{name, value})
+	return e
+}
+
+// WithBody requires the request body to equal body.
+func (e *Expectation) WithBody(body string) *Expectation {
+	e.body = func(got []byte) bool { return string(got) == body }
+	return e
+}
+
+// WithJSONBody requires the request body to be JSON equal to v, ignoring
+// formatting and key order.
+func (e *Expectation) WithJSONBody(v any) *Expectation {
+	want, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpxtest: marshal expected body: %v", err))
+	}
+	e.body = func(got []byte) bool {
+		var a, b any
+		if json.Unmarshal(got, &a) != nil || json.Unmarshal(want, &b) != nil {
+			return false
+		}
+		return reflect.DeepEqual(a, b)
+	}
+	return e
+}
+
+// Once expects exactly one call. This is the default.
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Times expects exactly n calls.
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// AnyTimes allows any number of calls, including none.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.min, e.max = 0, unlimited
+	return e
+}
+
+// Respond sets the response status and body.
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	e.status = status
+	e.payload = []byte(body)
+	return e
+}
+
+// RespondJSON sets the response status and encodes v as a JSON body.
+func (e *Expectation) RespondJSON(status int, v any) *Expectation {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpxtest: marshal response body: %v", err))
+	}
+	e.status = status
+	e.payload = payload
+	e.header.Set("Content-Type", "application/json")
+	return e
+}
+
+// RespondHeader adds a response header.
+func (e *Expectation) RespondHeader(name, value string) *Expectation {
+	e.header.Add(name, value)
+	return e
+}
+
+// RespondWith answers matching requests with h. Path parameters are
+// available through r.PathValue.
+func (e *Expectation) RespondWith(h http.HandlerFunc) *Expectation {
+	e.handler = h
+	return e
+}
+
+func (e *Expectation) respond(w http.ResponseWriter, r *http.Request) {
+	if e.handler != nil {
+		e.handler(w, r)
+		return
+	}
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.payload)
+}
+
+func (e *Expectation) matches(r *http.Request, body []byte) (map[string]string, bool) {
+	if e.method != r.Method {
+		return nil, false
+	}
+	params, ok := matchPath(e.pattern, r.URL.Path)
+	if !ok {
+		return nil, false
+	}
+	for _, h := range e.headers {
+		if !containsValue(r.Header.Values(h[0]), h[1]) {
+			return nil, false
+		}
+	}
+	query := r.URL.Query()
+	for _, q := range e.query {
+		if !containsValue(query[q[0]], q[1]) {
+			return nil, false
+		}
+	}
+	if e.body != nil && !e.body(body) {
+		return nil, false
+	}
+	return params, true
+}
+
+func (e *Expectation) times() string {
+	if e.min == 1 {
+		return "1 time"
+	}
+	return fmt.Sprintf("%d times", e.min)
+}
+
+func matchPath(pattern, path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	var params map[string]string
+	set := func(name, value string) {
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[name] = value
+	}
+	for i, segment := range want {
+		name, isParam := strings.CutPrefix(segment, "{")
+		name, closed := strings.CutSuffix(name, "}")
+		isParam = isParam && closed
+		if isParam && strings.HasSuffix(name, "...") && i == len(want)-1 {
+			set(strings.TrimSuffix(name, "..."), strings.Join(got[min(i, len(got)):], "/"))
+			return params, true
+		}
+		if i >= len(got) {
+			return nil, false
+		}
+		switch {
+		case isParam && got[i] != "":
+			set(name, got[i])
+		case segment != got[i]:
+			return nil, false
+		}
+	}
+	if len(got) != len(want) {
+		return nil, false
+	}
+	return params, true
+}
+
+func containsValue(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
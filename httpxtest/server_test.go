@@ -0,0 +1,148 @@
+package httpxtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/goforj/httpx/v2"
+)
+
+// recordingTB captures failures and cleanups so verification can be checked.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *recordingTB) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestServerExpectations(t *testing.T) {
+	srv := NewServer(t)
+	srv.Expect("GET", "/users/{id}").
+		WithHeader("X-Tenant", "acme").
+		RespondJSON(http.StatusOK, user{ID: "42", Name: "Ana"})
+	srv.Expect("POST", "/users").
+		WithJSONBody(map[string]any{"name": "Bo"}).
+		Times(2).
+		RespondWith(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"43","name":"Bo"}`))
+		})
+	srv.Expect("GET", "/files/{path...}").
+		AnyTimes().
+		RespondWith(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.PathValue("path")))
+		})
+
+	c := srv.Client(httpx.Header("X-Tenant", "acme"))
+	got, err := httpx.Get[user](c, "/users/42")
+	if err != nil || got.Name != "Ana" {
+		t.Fatalf("get user = %+v, %v", got, err)
+	}
+	for range 2 {
+		created, err := httpx.Post[map[string]string, user](c, "/users", map[string]string{"name": "Bo"})
+		if err != nil || created.ID != "43" {
+			t.Fatalf("create user = %+v, %v", created, err)
+		}
+	}
+	path, err := httpx.Get[string](c, "/files/a/b.txt")
+	if err != nil || path != "a/b.txt" {
+		t.Fatalf("wildcard = %q, %v", path, err)
+	}
+}
+
+func TestServerVerify(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	srv := NewServer(tb)
+	srv.Expect("GET", "/once").Respond(http.StatusNoContent, "")
+	srv.Expect("DELETE", "/never").Respond(http.StatusNoContent, "")
+
+	c := srv.Client()
+	for range 2 {
+		_, _ = httpx.Get[string](c, "/once")
+	}
+	_, err := httpx.Get[string](c, "/other")
+	var httpErr *httpx.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("expected 501 for unmatched request, got %v", err)
+	}
+
+	tb.runCleanups()
+	got := strings.Join(tb.errors, "\n")
+	for _, want := range []string{
+		"unexpected request GET /once (called more times than expected)",
+		"unexpected request GET /other",
+		"expected DELETE /never to be called 1 time, got 0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestServerInOrder(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	srv := NewServer(tb).InOrder()
+	srv.Expect("POST", "/login").Respond(http.StatusOK, "token")
+	srv.Expect("GET", "/poll").AnyTimes()
+	srv.Expect("POST", "/logout")
+
+	c := srv.Client()
+	if _, err := httpx.Post[any, string](c, "/logout", nil); err == nil {
+		t.Fatalf("expected logout before login to fail")
+	}
+	for _, step := range []struct{ method, path string }{{"POST", "/login"}, {"GET", "/poll"}, {"GET", "/poll"}, {"POST", "/logout"}} {
+		r := c.Req().R().SetURL(step.path)
+		r.Method = step.method
+		if _, _, err := httpx.Do[string](r); err != nil {
+			t.Fatalf("%s %s: %v", step.method, step.path, err)
+		}
+	}
+
+	tb.runCleanups()
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "POST /logout (next expected POST /login)") {
+		t.Fatalf("errors = %v", tb.errors)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		ok            bool
+		params        map[string]string
+	}{
+		{"/users/{id}", "/users/7", true, map[string]string{"id": "7"}},
+		{"/users/{id}", "/users/7/posts", false, nil},
+		{"/users/{id}", "/users/", false, nil},
+		{"/static/{rest...}", "/static", true, map[string]string{"rest": ""}},
+		{"/", "/", true, nil},
+	}
+	for _, tc := range cases {
+		params, ok := matchPath(tc.pattern, tc.path)
+		if ok != tc.ok || fmt.Sprint(params) != fmt.Sprint(tc.params) {
+			t.Fatalf("matchPath(%q, %q) = %v, %v", tc.pattern, tc.path, params, ok)
+		}
+	}
+}
@@ -2,7 +2,17 @@ package httpx
 
 import (
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/icholy/digest"
 	"github.com/imroc/req/v3"
 )
 
@@ -125,3 +135,266 @@ func (b OptionBuilder) Basic(user, pass string) OptionBuilder {
 		},
 	))
 }
+
+// Digest enables HTTP Digest authentication (RFC 7616).
+// A 401 challenge is answered by replaying the request with credentials, and the
+// challenge is cached per origin and realm so later requests in the same
+// protection space authenticate up front with an incrementing nonce count.
+// SHA-512, SHA-512-256, SHA-256 and MD5 are supported (the strongest offered
+// challenge wins, in that order) with qop=auth or qop=auth-int. Request bodies are buffered so
+// they can be replayed after a challenge.
+// @group Auth
+//
+// Applies to client configuration only.
+// Apply Digest after Transport, which replaces previously wrapped round trippers.
+// Example: digest auth
+//
+//	c := httpx.New(httpx.Digest("user", "pass"))
+//	res, _ := httpx.Get[map[string]any](c, "https://httpbin.org/digest-auth/auth/user/pass/SHA-256")
+//	httpx.Dump(res) // dumps map[string]any
+//	// #map[string]interface {} {
+//	//   authenticated => true #bool
+//	//   user          => "user" #string
+//	// }
+func Digest(user, pass string) OptionBuilder {
+	return OptionBuilder{}.Digest(user, pass)
+}
+
+func (b OptionBuilder) Digest(user, pass string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		auth := &digestAuth{user: user, pass: pass, spaces: map[string][]*digestSpace{}}
+		c.req.Transport.WrapRoundTripFunc(auth.wrap)
+	}))
+}
+
+type digestAuth struct {
+	user string
+	pass string
+
+	mu     sync.Mutex
+	spaces map[string][]*digestSpace
+}
+
+// digestSpace is a cached challenge for one realm on an origin, along with the
+// path prefixes it protects and the last nonce count sent with it.
+type digestSpace struct {
+	chal  *digest.Challenge
+	scope []string
+	count int
+}
+
+func (d *digestAuth) wrap(rt http.RoundTripper) req.HttpRoundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		first := r.Clone(r.Context())
+		if _, err := bufferRequestBody(first); err != nil {
+			return nil, err
+		}
+		sent, err := d.authorize(first)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := rt.RoundTrip(first)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		chal, err := preferredDigestChallenge(resp.Header)
+		if err != nil {
+			d.forget(first)
+			if errors.Is(err, digest.ErrNoChallenge) {
+				return resp, nil
+			}
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("httpx: digest challenge: %w", err)
+		}
+		// A rejected request that was already using this exact challenge means
+		// the credentials are wrong, not that the nonce expired.
+		if sent && !chal.Stale && d.current(first, chal) {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		d.remember(first, chal)
+
+		second := first.Clone(first.Context())
+		if first.GetBody != nil {
+			if second.Body, err = first.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := d.authorize(second); err != nil {
+			return nil, err
+		}
+		return rt.RoundTrip(second)
+	}
+}
+
+// authorize sets the Authorization header from the cached challenge covering r,
+// reporting whether one was found.
+func (d *digestAuth) authorize(r *http.Request) (bool, error) {
+	d.mu.Lock()
+	space := d.lookup(r)
+	if space == nil {
+		d.mu.Unlock()
+		return false, nil
+	}
+	space.count++
+	chal, count := space.chal, space.count
+	d.mu.Unlock()
+
+	cred, err := digest.Digest(chal, digest.Options{
+		Method:   r.Method,
+		URI:      r.URL.RequestURI(),
+		GetBody:  r.GetBody,
+		Count:    count,
+		Username: d.user,
+		Password: d.pass,
+	})
+	if err != nil {
+		return true, fmt.Errorf("httpx: digest auth: %w", err)
+	}
+	r.Header.Set("Authorization", cred.String())
+	return true, nil
+}
+
+// lookup returns the cached space on r's origin with the longest matching scope.
+// The caller must hold d.mu.
+func (d *digestAuth) lookup(r *http.Request) *digestSpace {
+	var best *digestSpace
+	bestLen := -1
+	for _, space := range d.spaces[digestOrigin(r)] {
+		for _, prefix := range space.scope {
+			if strings.HasPrefix(digestPath(r.URL), prefix) && len(prefix) > bestLen {
+				best, bestLen = space, len(prefix)
+			}
+		}
+	}
+	return best
+}
+
+func (d *digestAuth) current(r *http.Request, chal *digest.Challenge) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	space := d.lookup(r)
+	return space != nil && space.chal.Realm == chal.Realm && space.chal.Nonce == chal.Nonce
+}
+
+// remember caches chal for its realm, replacing any previous challenge (and
+// resetting the nonce count) while keeping the scope learned so far.
+func (d *digestAuth) remember(r *http.Request, chal *digest.Challenge) {
+	origin := digestOrigin(r)
+	scope := digestScope(r, chal)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, space := range d.spaces[origin] {
+		if space.chal.Realm == chal.Realm {
+			space.chal = chal
+			space.count = 0
+			for _, prefix := range scope {
+				if !slices.Contains(space.scope, prefix) {
+					space.scope = append(space.scope, prefix)
+				}
+			}
+			return
+		}
+	}
+	d.spaces[origin] = append(d.spaces[origin], &digestSpace{chal: chal, scope: scope})
+}
+
+func (d *digestAuth) forget(r *http.Request) {
+	origin := digestOrigin(r)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	space := d.lookup(r)
+	if space == nil {
+		return
+	}
+	spaces := d.spaces[origin][:0]
+	for _, s := range d.spaces[origin] {
+		if s != space {
+			spaces = append(spaces, s)
+		}
+	}
+	d.spaces[origin] = spaces
+}
+
+// digestPath returns the path of u, which is "/" when the URL has none.
+func digestPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+func digestOrigin(r *http.Request) string {
+	return strings.ToLower(r.URL.Scheme + "://" + r.URL.Host)
+}
+
+// digestScope returns the path prefixes a challenge protects: the paths listed
+// in its domain parameter, or otherwise the directory of the challenged request.
+func digestScope(r *http.Request, chal *digest.Challenge) []string {
+	var scope []string
+	for _, uri := range chal.Domain {
+		if u, err := r.URL.Parse(uri); err == nil && digestOrigin(&http.Request{URL: u}) == digestOrigin(r) {
+			scope = append(scope, u.Path)
+		}
+	}
+	if len(scope) == 0 {
+		dir := path.Dir(digestPath(r.URL))
+		if !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+		scope = append(scope, dir)
+	}
+	return scope
+}
+
+// preferredDigestChallenge picks the strongest supported Digest challenge from
+// the WWW-Authenticate headers, as RFC 7616 asks clients to do.
+func preferredDigestChallenge(h http.Header) (*digest.Challenge, error) {
+	var best *digest.Challenge
+	bestRank := -1
+	var last error
+	for _, value := range h.Values("WWW-Authenticate") {
+		if !digest.IsDigest(value) {
+			continue
+		}
+		chal, err := digest.ParseChallenge(value)
+		if err != nil {
+			last = err
+			continue
+		}
+		for i, qop := range chal.QOP {
+			chal.QOP[i] = strings.TrimSpace(qop)
+		}
+		if !digest.CanDigest(chal) {
+			continue
+		}
+		if rank := digestAlgorithmRank(chal.Algorithm); rank > bestRank {
+			best, bestRank = chal, rank
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+	if last != nil {
+		return nil, last
+	}
+	return nil, digest.ErrNoChallenge
+}
+
+// digestAlgorithmRank orders algorithms by digest strength.
+func digestAlgorithmRank(alg string) int {
+	switch strings.ToUpper(alg) {
+	case "SHA-512":
+		return 4
+	case "SHA-512-256":
+		return 3
+	case "SHA-256":
+		return 2
+	case "MD5", "":
+		return 1
+	default:
+		return 0
+	}
+}
@@ -1,10 +1,18 @@
 package httpx
 
 import (
+	"bytes"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/icholy/digest"
 )
 
 func TestAuthHeaders(t *testing.T) {
@@ -53,3 +61,196 @@ func TestBearerAndBasic(t *testing.T) {
 		t.Fatalf("basic header = %q", auths[1])
 	}
 }
+
+type digestServer struct {
+	realm     string
+	pass      string
+	qop       string
+	algs      []string
+	maxUses   int
+	mu        sync.Mutex
+	nonce     int
+	uses      int
+	hits      int
+	counts    []int
+	algorithm []string
+	bodies    []string
+}
+
+func (s *digestServer) challenge(w http.ResponseWriter, stale bool) {
+	for _, alg := range s.algs {
+		chal := &digest.Challenge{
+			Realm:     s.realm,
+			Nonce:     fmt.Sprintf("nonce-%d", s.nonce),
+			Algorithm: alg,
+			Opaque:    "opaque",
+			Stale:     stale,
+		}
+		if s.qop != "" {
+			chal.QOP = strings.Split(s.qop, ",")
+		}
+		w.Header().Add("WWW-Authenticate", chal.String())
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits++
+	cred, err := digest.ParseCredentials(r.Header.Get("Authorization"))
+	if err != nil {
+		s.challenge(w, false)
+		return
+	}
+	if cred.Nonce != fmt.Sprintf("nonce-%d", s.nonce) {
+		s.challenge(w, true)
+		return
+	}
+	if s.maxUses > 0 && s.uses >= s.maxUses {
+		s.nonce++
+		s.uses = 0
+		s.challenge(w, true)
+		return
+	}
+	chal := &digest.Challenge{Realm: s.realm, Nonce: cred.Nonce, Algorithm: cred.Algorithm, Opaque: "opaque"}
+	if cred.QOP != "" {
+		chal.QOP = []string{cred.QOP}
+	}
+	want, err := digest.Digest(chal, digest.Options{
+		Method:   r.Method,
+		URI:      r.URL.RequestURI(),
+		GetBody:  func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil },
+		Count:    cred.Nc,
+		Username: "user",
+		Password: s.pass,
+		Cnonce:   cred.Cnonce,
+	})
+	if err != nil || want.Response != cred.Response || cred.URI != r.URL.RequestURI() {
+		s.challenge(w, false)
+		return
+	}
+	s.uses++
+	s.counts = append(s.counts, cred.Nc)
+	s.algorithm = append(s.algorithm, cred.Algorithm+"/"+cred.QOP)
+	s.bodies = append(s.bodies, string(body))
+	_, _ = w.Write([]byte("ok"))
+}
+
+func TestDigestReusesChallenge(t *testing.T) {
+	ds := &digestServer{realm: "devices", pass: "pass", qop: "auth", algs: []string{"MD5", "SHA-256"}}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	c := New(Digest("user", "pass"))
+	for i := 0; i < 3; i++ {
+		res, err := Get[string](c, srv.URL+"/api/status")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if res != "ok" {
+			t.Fatalf("response = %q", res)
+		}
+	}
+	if ds.hits != 4 {
+		t.Fatalf("hits = %d, want one challenge then cached auth", ds.hits)
+	}
+	if fmt.Sprint(ds.counts) != "[1 2 3]" {
+		t.Fatalf("nonce counts = %v", ds.counts)
+	}
+	if ds.algorithm[0] != "SHA-256/auth" {
+		t.Fatalf("algorithm = %q", ds.algorithm[0])
+	}
+}
+
+func TestDigestPrefersStrongestAlgorithm(t *testing.T) {
+	ds := &digestServer{realm: "devices", pass: "pass", qop: "auth", algs: []string{"MD5", "SHA-512", "SHA-256", "SHA-512-256"}}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	if _, err := Get[string](New(Digest("user", "pass")), srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if ds.algorithm[0] != "SHA-512/auth" {
+		t.Fatalf("algorithm = %q", ds.algorithm[0])
+	}
+}
+
+func TestDigestAuthIntBody(t *testing.T) {
+	ds := &digestServer{realm: "devices", pass: "pass", qop: "auth-int", algs: []string{"MD5"}}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	c := New(Digest("user", "pass"))
+	_, err := Post[string, string](c, srv.URL+"/config", "reboot=1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_, err = Post[io.Reader, string](c, srv.URL+"/config", strings.NewReader("reboot=2"))
+	if err != nil {
+		t.Fatalf("streamed request failed: %v", err)
+	}
+	if fmt.Sprint(ds.bodies) != "[reboot=1 reboot=2]" {
+		t.Fatalf("bodies = %v", ds.bodies)
+	}
+	if ds.algorithm[0] != "MD5/auth-int" {
+		t.Fatalf("algorithm = %q", ds.algorithm[0])
+	}
+}
+
+func TestDigestStaleNonce(t *testing.T) {
+	ds := &digestServer{realm: "devices", pass: "pass", qop: "auth", algs: []string{"SHA-256"}, maxUses: 2}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	c := New(Digest("user", "pass"))
+	for i := 0; i < 3; i++ {
+		if _, err := Get[string](c, srv.URL+"/"); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+	if fmt.Sprint(ds.counts) != "[1 2 1]" {
+		t.Fatalf("nonce counts = %v", ds.counts)
+	}
+}
+
+func TestDigestWrongPassword(t *testing.T) {
+	ds := &digestServer{realm: "devices", pass: "secret", algs: []string{"MD5"}}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	c := New(Digest("user", "wrong"))
+	_, err := Get[string](c, srv.URL+"/")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+	if ds.hits != 2 {
+		t.Fatalf("hits = %d", ds.hits)
+	}
+	_, _ = Get[string](c, srv.URL+"/")
+	if ds.hits != 3 {
+		t.Fatalf("expected cached challenge not to be retried, hits = %d", ds.hits)
+	}
+}
+
+func TestDigestScopeByRealm(t *testing.T) {
+	admin := &digestServer{realm: "admin", pass: "pass", qop: "auth", algs: []string{"SHA-256"}}
+	api := &digestServer{realm: "api", pass: "pass", qop: "auth", algs: []string{"SHA-256"}}
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", admin)
+	mux.Handle("/api/", api)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(Digest("user", "pass"))
+	for _, p := range []string{"/admin/a", "/api/a", "/admin/b", "/api/b"} {
+		if _, err := Get[string](c, srv.URL+p); err != nil {
+			t.Fatalf("request %s failed: %v", p, err)
+		}
+	}
+	if admin.hits != 3 || api.hits != 3 {
+		t.Fatalf("hits admin=%d api=%d", admin.hits, api.hits)
+	}
+}
@@ -0,0 +1,231 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+	"github.com/imroc/req/v3"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultCompressMinSize is the smallest body CompressBody encodes unless
+// CompressBodyMinSize says otherwise.
+const defaultCompressMinSize = 1 << 10
+
+// CompressionAlgorithm names a Content-Encoding for CompressBody.
+type CompressionAlgorithm string
+
+const (
+	CompressionGzip   CompressionAlgorithm = "gzip"
+	CompressionZstd   CompressionAlgorithm = "zstd"
+	CompressionBrotli CompressionAlgorithm = "br"
+)
+
+// CompressBody compresses request bodies with algo and sets Content-Encoding.
+// Level 0 selects the default level of the algorithm; otherwise it ranges
+// from 1 to 9 for gzip, 1 to 22 for zstd and 1 to 11 for brotli. Bodies are
+// encoded while they are sent, so they are not held in memory, and every
+// attempt, including retries, encodes the body again. Bodies under 1 KiB are
+// sent as they are, see CompressBodyMinSize, as are bodies that already have
+// a Content-Encoding.
+// @group Request Composition
+//
+// Applies to both client defaults and individual requests.
+// Example: gzip large JSON batches
+//
+//	batch := []map[string]any{{"event": "signup"}, {"event": "login"}}
+//
+//	// Apply to all requests
+//	c := httpx.New(httpx.CompressBody(httpx.CompressionGzip, 0))
+//	_, _ = httpx.Post[[]map[string]any, map[string]any](c, "https://example.com/ingest", batch)
+//
+//	// Apply to a single request
+//	_, _ = httpx.Post[[]map[string]any, map[string]any](c, "https://example.com/ingest", batch,
+//		httpx.CompressBody(httpx.CompressionZstd, 3),
+//	)
+func CompressBody(algo CompressionAlgorithm, level int) OptionBuilder {
+	return OptionBuilder{}.CompressBody(algo, level)
+}
+
+func (b OptionBuilder) CompressBody(algo CompressionAlgorithm, level int) OptionBuilder {
+	return b.add(bothOption(
+		func(c *Client) {
+			c.compressBodies(algo, level)
+		},
+		func(r *req.Request) {
+			if c := boundClient(r, nil); c != nil {
+				c.compressBodies(algo, level)
+			}
+		},
+	))
+}
+
+// CompressBodyMinSize sets the smallest body, in bytes, that CompressBody
+// encodes. Smaller bodies gain little and are sent as they are. The default
+// is 1 KiB.
+// @group Request Composition
+//
+// Applies to both client defaults and individual requests.
+// Example: compress every body
+//
+//	c := httpx.New(httpx.CompressBody(httpx.CompressionBrotli, 0), httpx.CompressBodyMinSize(0))
+//	_, _ = httpx.Post[map[string]any, map[string]any](c, "https://example.com/ingest", map[string]any{"ok": true})
+func CompressBodyMinSize(n int64) OptionBuilder {
+	return OptionBuilder{}.CompressBodyMinSize(n)
+}
+
+func (b OptionBuilder) CompressBodyMinSize(n int64) OptionBuilder {
+	setMinSize := func(c *Client) {
+		cfg := c.compress.clone()
+		cfg.minSize = n
+		c.compress = cfg
+	}
+	return b.add(bothOption(
+		setMinSize,
+		func(r *req.Request) {
+			if c := boundClient(r, nil); c != nil {
+				setMinSize(c)
+			}
+		},
+	))
+}
+
+// compressConfig is shared by clients until an option changes it.
+type compressConfig struct {
+	algo    CompressionAlgorithm
+	level   int
+	minSize int64
+	// installed is set once the transport of the client encodes bodies.
+	installed bool
+}
+
+func (c *compressConfig) clone() *compressConfig {
+	if c == nil {
+		return &compressConfig{minSize: defaultCompressMinSize}
+	}
+	cp := *c
+	return &cp
+}
+
+func (c *Client) compressBodies(algo CompressionAlgorithm, level int) {
+	enc, err := newBodyEncoder(io.Discard, algo, level)
+	if err != nil {
+		failRequests(c, err)
+		return
+	}
+	_ = enc.Close()
+	cfg := c.compress.clone()
+	cfg.algo = algo
+	cfg.level = level
+	installed := cfg.installed
+	cfg.installed = true
+	c.compress = cfg
+	if installed {
+		return
+	}
+	c.req.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			cfg := c.compress
+			if bound, ok := r.Context().Value(clientKey{}).(*Client); ok {
+				cfg = bound.compress
+			}
+			if cfg == nil || cfg.algo == "" || r.Body == nil || r.Body == http.NoBody || r.Header.Get("Content-Encoding") != "" {
+				return rt.RoundTrip(r)
+			}
+			if r.ContentLength > 0 && r.ContentLength < cfg.minSize {
+				return rt.RoundTrip(r)
+			}
+			r = r.Clone(r.Context())
+			if r.ContentLength <= 0 && cfg.minSize > 0 {
+				// The size is unknown, so read far enough to tell.
+				head := make([]byte, cfg.minSize)
+				n, err := io.ReadFull(r.Body, head)
+				switch err {
+				case nil:
+					r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+				case io.EOF, io.ErrUnexpectedEOF:
+					_ = r.Body.Close()
+					r.Body = io.NopCloser(bytes.NewReader(head[:n]))
+					r.ContentLength = int64(n)
+					if n == 0 {
+						r.Body = http.NoBody
+					}
+					return rt.RoundTrip(r)
+				default:
+					_ = r.Body.Close()
+					return nil, err
+				}
+			}
+			r.Header.Set("Content-Encoding", string(cfg.algo))
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			r.Body = cfg.encode(r.Body)
+			if getBody := r.GetBody; getBody != nil {
+				r.GetBody = func() (io.ReadCloser, error) {
+					body, err := getBody()
+					if err != nil {
+						return nil, err
+					}
+					return cfg.encode(body), nil
+				}
+			}
+			return rt.RoundTrip(r)
+		}
+	})
+}
+
+// encode returns body compressed as it is read.
+func (c *compressConfig) encode(body io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		enc, err := newBodyEncoder(pw, c.algo, c.level)
+		if err == nil {
+			_, err = io.Copy(enc, body)
+			if closeErr := enc.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		_ = body.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func newBodyEncoder(w io.Writer, algo CompressionAlgorithm, level int) (io.WriteCloser, error) {
+	switch algo {
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+			return nil, fmt.Errorf("httpx: invalid gzip level %d", level)
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CompressionZstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			if level < 1 || level > 22 {
+				return nil, fmt.Errorf("httpx: invalid zstd level %d", level)
+			}
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	case CompressionBrotli:
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		if level < 1 || level > brotli.BestCompression {
+			return nil, fmt.Errorf("httpx: invalid brotli level %d", level)
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	}
+	return nil, fmt.Errorf("httpx: unsupported compression algorithm %q", algo)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
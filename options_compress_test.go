@@ -0,0 +1,210 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/imroc/req/v3"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+type compressedRequest struct {
+	encoding      string
+	contentLength int64
+	body          string
+}
+
+type compressServer struct {
+	*httptest.Server
+	requests []compressedRequest
+	status   func(attempt int) int
+}
+
+func newCompressServer(t *testing.T) *compressServer {
+	t.Helper()
+	s := &compressServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		switch r.Header.Get("Content-Encoding") {
+		case "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		case "zstd":
+			zr, err := zstd.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			body = zr
+		case "br":
+			body = brotli.NewReader(r.Body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.requests = append(s.requests, compressedRequest{
+			encoding:      r.Header.Get("Content-Encoding"),
+			contentLength: r.ContentLength,
+			body:          string(data),
+		})
+		status := http.StatusOK
+		if s.status != nil {
+			status = s.status(len(s.requests))
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCompressBody(t *testing.T) {
+	payload := strings.Repeat(`{"event":"signup"},`, 200)
+	for _, tc := range []struct {
+		algo  CompressionAlgorithm
+		level int
+	}{
+		{CompressionGzip, 0},
+		{CompressionGzip, 9},
+		{CompressionZstd, 0},
+		{CompressionZstd, 19},
+		{CompressionBrotli, 0},
+		{CompressionBrotli, 1},
+	} {
+		srv := newCompressServer(t)
+		_, err := Post[string, map[string]any](New(), srv.URL, payload, CompressBody(tc.algo, tc.level))
+		if err != nil {
+			t.Fatalf("%s/%d: request failed: %v", tc.algo, tc.level, err)
+		}
+		got := srv.requests[0]
+		if got.encoding != string(tc.algo) || got.body != payload || got.contentLength != -1 {
+			t.Fatalf("%s/%d: unexpected request %q (length %d, %d bytes)", tc.algo, tc.level, got.encoding, got.contentLength, len(got.body))
+		}
+	}
+}
+
+func TestCompressBodySkipsSmallBodies(t *testing.T) {
+	srv := newCompressServer(t)
+	c := New(CompressBody(CompressionGzip, 0))
+
+	if _, err := Post[string, map[string]any](c, srv.URL, "small"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, err := Post[any, map[string]any](c, srv.URL, nil, Body(strings.NewReader("small stream"))); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, err := Post[string, map[string]any](c, srv.URL, "small", CompressBodyMinSize(0)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	want := []compressedRequest{
+		{encoding: "", contentLength: 5, body: "small"},
+		{encoding: "", contentLength: 12, body: "small stream"},
+		{encoding: "gzip", contentLength: -1, body: "small"},
+	}
+	for i, w := range want {
+		if srv.requests[i] != w {
+			t.Fatalf("request %d: got %+v, want %+v", i, srv.requests[i], w)
+		}
+	}
+}
+
+func TestCompressBodyStreamsUnknownSize(t *testing.T) {
+	srv := newCompressServer(t)
+	payload := strings.Repeat("x", 4<<10)
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 4; i++ {
+			_, _ = pw.Write([]byte(payload[i<<10 : (i+1)<<10]))
+		}
+		_ = pw.Close()
+	}()
+
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Body(pr), CompressBody(CompressionZstd, 0))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := srv.requests[0]; got.encoding != "zstd" || got.body != payload {
+		t.Fatalf("unexpected request %q with %d bytes", got.encoding, len(got.body))
+	}
+}
+
+func TestCompressBodyReencodesOnRetry(t *testing.T) {
+	srv := newCompressServer(t)
+	srv.status = func(attempt int) int {
+		if attempt == 1 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}
+	payload := strings.Repeat("retry ", 400)
+
+	_, err := Post[string, map[string]any](New(CompressBody(CompressionBrotli, 0)), srv.URL, payload,
+		RetryCount(1),
+		RetryFixedInterval(0),
+		RetryCondition(func(resp *req.Response, _ error) bool {
+			return resp != nil && resp.StatusCode == http.StatusServiceUnavailable
+		}),
+	)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(srv.requests) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(srv.requests))
+	}
+	for i, got := range srv.requests {
+		if got.encoding != "br" || got.body != payload {
+			t.Fatalf("attempt %d: unexpected request %q with %d bytes", i, got.encoding, len(got.body))
+		}
+	}
+}
+
+func TestCompressBodyKeepsExistingEncoding(t *testing.T) {
+	srv := newCompressServer(t)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(bytes.Repeat([]byte("a"), 2<<10))
+	_ = zw.Close()
+
+	_, err := Post[[]byte, map[string]any](New(CompressBody(CompressionZstd, 0), CompressBodyMinSize(0)), srv.URL, buf.Bytes(),
+		Header("Content-Encoding", "gzip"),
+	)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := srv.requests[0]; got.encoding != "gzip" || len(got.body) != 2<<10 {
+		t.Fatalf("expected body to keep its encoding, got %q with %d bytes", got.encoding, len(got.body))
+	}
+}
+
+func TestCompressBodyInvalid(t *testing.T) {
+	srv := newCompressServer(t)
+	for _, opt := range []OptionBuilder{
+		CompressBody("lz4", 0),
+		CompressBody(CompressionGzip, 10),
+		CompressBody(CompressionZstd, 23),
+		CompressBody(CompressionBrotli, 12),
+	} {
+		if _, err := Post[string, map[string]any](New(opt), srv.URL, "x"); err == nil || !strings.Contains(err.Error(), "httpx: ") {
+			t.Fatalf("expected configuration error, got %v", err)
+		}
+		if _, err := Post[string, map[string]any](New(), srv.URL, "x", opt); err == nil {
+			t.Fatalf("expected configuration error for request option")
+		}
+	}
+	if len(srv.requests) != 0 {
+		t.Fatalf("expected no requests to be sent, got %d", len(srv.requests))
+	}
+}
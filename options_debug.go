@@ -1,7 +1,13 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
 
 	"github.com/imroc/req/v3"
 )
@@ -25,10 +31,14 @@ func EnableDump() OptionBuilder {
 func (b OptionBuilder) EnableDump() OptionBuilder {
 	return b.add(requestOnly(func(r *req.Request) {
 		r.EnableDump()
+		if _, ok := r.Context().Value(dumpTargetKey{}).(io.Writer); !ok {
+			r.SetContext(context.WithValue(r.Context(), dumpInMemoryKey{}, true))
+		}
 	}))
 }
 
 // DumpTo enables req's request-level dump output to a writer.
+// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 // @group Debugging
 //
 // Applies to individual requests only.
@@ -47,11 +57,12 @@ func DumpTo(output io.Writer) OptionBuilder {
 
 func (b OptionBuilder) DumpTo(output io.Writer) OptionBuilder {
 	return b.add(requestOnly(func(r *req.Request) {
-		r.EnableDumpTo(output)
+		dumpRedacted(r, output)
 	}))
 }
 
 // DumpToFile enables req's request-level dump output to a file path.
+// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 // @group Debugging
 //
 // Applies to individual requests only.
@@ -69,11 +80,18 @@ func DumpToFile(filename string) OptionBuilder {
 
 func (b OptionBuilder) DumpToFile(filename string) OptionBuilder {
 	return b.add(requestOnly(func(r *req.Request) {
-		r.EnableDumpToFile(filename)
+		file, err := os.Create(filename)
+		if err != nil {
+			// Let req record the failure on the request.
+			r.EnableDumpToFile(filename)
+			return
+		}
+		dumpRedacted(r, file)
 	}))
 }
 
 // DumpAll enables req's client-level dump output for all requests.
+// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 // @group Debugging
 //
 // Applies to the client configuration only.
@@ -91,7 +109,7 @@ func DumpAll() OptionBuilder {
 
 func (b OptionBuilder) DumpAll() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.EnableDumpAll()
+		c.dumpAllRedacted(os.Stdout, func() *redaction { return c.redact })
 	}))
 }
 
@@ -118,6 +136,7 @@ func (b OptionBuilder) DumpEachRequest() OptionBuilder {
 }
 
 // DumpEachRequestTo enables request-level dumps for each request and writes them to the provided output.
+// Secrets are masked as described by RedactHeaders, RedactQuery and RedactJSONFields.
 // @group Debugging
 //
 // Applies to the client configuration only.
@@ -145,7 +164,7 @@ func (b OptionBuilder) DumpEachRequestTo(output io.Writer) OptionBuilder {
 			if resp == nil {
 				return nil
 			}
-			_, _ = output.Write([]byte(resp.Dump()))
+			_, _ = output.Write([]byte(redactDump(resp.Dump(), boundClient(resp.Request, c).redact)))
 			return nil
 		})
 	}))
@@ -194,3 +213,173 @@ func (b OptionBuilder) TraceAll() OptionBuilder {
 		c.req.EnableTraceAll()
 	}))
 }
+
+type dumpTargetKey struct{}
+
+// dumpInMemoryKey marks a request whose dump EnableDump keeps in req's
+// in-memory buffer.
+type dumpInMemoryKey struct{}
+
+// dumpRedacted dumps r to output through a redactWriter using the redaction of
+// the client r was built from. A dump that DumpTo or DumpToFile already set up
+// on r keeps receiving output, since req holds one target.
+func dumpRedacted(r *req.Request, output io.Writer) *redactWriter {
+	var redact *redaction
+	if c := boundClient(r, nil); c != nil {
+		redact = c.redact
+	}
+	w := newRedactWriter(output, func() *redaction { return redact })
+	var target io.Writer = w
+	if prev, ok := r.Context().Value(dumpTargetKey{}).(io.Writer); ok {
+		target = io.MultiWriter(prev, w)
+	}
+	ctx := context.WithValue(r.Context(), dumpTargetKey{}, target)
+	r.SetContext(context.WithValue(ctx, dumpInMemoryKey{}, false))
+	r.EnableDumpTo(target)
+	r.OnAfterResponse(func(*req.Client, *req.Response) error {
+		return w.Flush()
+	})
+	return w
+}
+
+// dumpAllRedacted enables client-level dumps to output through a redactWriter,
+// flushing held back output after every attempt.
+func (c *Client) dumpAllRedacted(output io.Writer, redact func() *redaction) {
+	if c.req.Transport.Dump != nil {
+		return
+	}
+	w := newRedactWriter(output, redact)
+	c.req.EnableDumpAllTo(w)
+	c.req.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			resp, err := rt.RoundTrip(r)
+			_ = w.Flush()
+			return resp, err
+		}
+	})
+}
+
+// DumpOnError captures the request and response dumps of each call in a
+// bounded in-memory buffer and writes them to output only when the call ends
+// in an error: an HTTP error status, a decode error or a transport error.
+// Successful calls are discarded unless DumpOnErrorSample selects them. Dumps
+// of every attempt, including retries, are kept, and secrets are masked as
+// described by RedactHeaders, RedactQuery and RedactJSONFields. Only calls made
+// through the request helpers (Get, Post, GetCtx, ...) are captured. Dumps
+// requested with EnableDump, DumpTo or DumpToFile on the same call are still
+// written as usual.
+// @group Debugging
+//
+// Applies to client configuration only.
+// Example: dump failed calls to stderr
+//
+//	c := httpx.New(httpx.DumpOnError(os.Stderr))
+//	_, err := httpx.Get[string](c, "https://httpbin.org/status/500")
+//	_ = err
+func DumpOnError(output io.Writer) OptionBuilder {
+	return OptionBuilder{}.DumpOnError(output)
+}
+
+func (b OptionBuilder) DumpOnError(output io.Writer) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		if output == nil {
+			return
+		}
+		cfg := &dumpOnError{output: output, limit: defaultDumpOnErrorLimit}
+		if c.dumpOnError != nil {
+			cfg.sample = c.dumpOnError.sample
+		}
+		c.dumpOnError = cfg
+	}))
+}
+
+// DumpOnErrorSample also writes the dumps of successful calls captured by
+// DumpOnError, for the given fraction of them between 0 and 1. It may be
+// given before or after DumpOnError.
+// @group Debugging
+//
+// Applies to client configuration only.
+// Example: keep one in a hundred successful exchanges
+//
+//	c := httpx.New(httpx.DumpOnError(os.Stderr).DumpOnErrorSample(0.01))
+//	_ = c
+func DumpOnErrorSample(rate float64) OptionBuilder {
+	return OptionBuilder{}.DumpOnErrorSample(rate)
+}
+
+func (b OptionBuilder) DumpOnErrorSample(rate float64) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := &dumpOnError{limit: defaultDumpOnErrorLimit, sample: rate}
+		if c.dumpOnError != nil {
+			cfg.output = c.dumpOnError.output
+			cfg.limit = c.dumpOnError.limit
+		}
+		c.dumpOnError = cfg
+	}))
+}
+
+// defaultDumpOnErrorLimit bounds the dump kept in memory for each call.
+const defaultDumpOnErrorLimit = 256 << 10
+
+type dumpOnError struct {
+	mu     sync.Mutex
+	output io.Writer
+	limit  int
+	sample float64
+}
+
+// startDumpOnError starts capturing r's dump and returns the function that
+// writes or discards it once the call's final response and error are known.
+func (c *Client) startDumpOnError(r *req.Request) func(*req.Response, error) {
+	cfg := c.dumpOnError
+	if cfg == nil || cfg.output == nil {
+		return func(*req.Response, error) {}
+	}
+	buf := &boundedBuffer{limit: cfg.limit}
+	redact := func() *redaction { return c.redact }
+	// EnableDump keeps the raw dump in req's buffer for Response.Dump, so
+	// copy it from there instead of replacing it with another target.
+	inMemory, _ := r.Context().Value(dumpInMemoryKey{}).(bool)
+	var w *redactWriter
+	if !inMemory {
+		w = dumpRedacted(r, buf)
+	}
+	return func(resp *req.Response, err error) {
+		if err == nil && (cfg.sample <= 0 || rand.Float64() >= cfg.sample) {
+			return
+		}
+		if inMemory {
+			w = newRedactWriter(buf, redact)
+			if resp != nil {
+				_, _ = io.WriteString(w, resp.Dump())
+			}
+		}
+		_ = w.Flush()
+		outcome := "sampled"
+		if err != nil {
+			outcome = err.Error()
+		}
+		cfg.mu.Lock()
+		defer cfg.mu.Unlock()
+		// Transport errors can leave the dump empty, so always say what failed.
+		_, _ = fmt.Fprintf(cfg.output, "[httpx: %s %s: %s]\n", r.Method, c.redact.url(r.URL), outcome)
+		_, _ = cfg.output.Write(buf.buf.Bytes())
+		if buf.dropped > 0 {
+			_, _ = fmt.Fprintf(cfg.output, "\n[httpx: %d bytes of dump omitted]\n", buf.dropped)
+		}
+	}
+}
+
+// boundedBuffer keeps the first limit bytes written to it and counts the rest.
+type boundedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	keep := min(len(p), max(b.limit-b.buf.Len(), 0))
+	b.buf.Write(p[:keep])
+	b.dropped += len(p) - keep
+	return len(p), nil
+}
@@ -2,10 +2,12 @@ package httpx
 
 import (
 	"bytes"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"unsafe"
 
@@ -139,3 +141,225 @@ func TestTraceAll(t *testing.T) {
 		t.Fatalf("expected trace to be enabled")
 	}
 }
+
+func TestDumpPathsRedactSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=server-secret")
+		_, _ = w.Write([]byte(`{"access_token":"response-secret"}`))
+	}))
+	defer srv.Close()
+
+	secrets := []string{"header-secret", "query-secret", "body-secret", "server-secret", "response-secret", "custom-secret"}
+	send := func(t *testing.T, c *Client, opts ...Option) {
+		t.Helper()
+		_, err := Post[map[string]string, string](c, srv.URL+"/login?api_key=query-secret", map[string]string{"password": "body-secret"},
+			append([]Option{Header("Authorization", "Bearer header-secret").Header("X-Custom", "custom-secret")}, opts...)...)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	redact := RedactHeaders("X-Custom").RedactQuery("api_key").RedactJSONFields("password", "access_token")
+	check := func(t *testing.T, out string) {
+		t.Helper()
+		if !strings.Contains(out, "REDACTED") || !strings.Contains(out, "/login") {
+			t.Fatalf("expected a redacted dump, got:\n%s", out)
+		}
+		for _, secret := range secrets {
+			if strings.Contains(out, secret) {
+				t.Fatalf("%s leaked into dump:\n%s", secret, out)
+			}
+		}
+	}
+
+	t.Run("DumpTo", func(t *testing.T) {
+		var buf bytes.Buffer
+		send(t, New(redact), DumpTo(&buf))
+		check(t, buf.String())
+	})
+	t.Run("DumpToFile", func(t *testing.T) {
+		path := t.TempDir() + "/dump.txt"
+		send(t, New(), redact, DumpToFile(path))
+		data, _ := os.ReadFile(path)
+		check(t, string(data))
+	})
+	t.Run("DumpEachRequestTo", func(t *testing.T) {
+		var buf bytes.Buffer
+		send(t, New(DumpEachRequestTo(&buf).RedactHeaders("X-Custom")), redact)
+		check(t, buf.String())
+	})
+	t.Run("HTTP_TRACE", func(t *testing.T) {
+		out := captureStdout(t, func() {
+			t.Setenv("HTTP_TRACE", "1")
+			send(t, New(redact))
+		})
+		check(t, out)
+	})
+	t.Run("HTTP_TRACE_REDACT", func(t *testing.T) {
+		out := captureStdout(t, func() {
+			t.Setenv("HTTP_TRACE", "1")
+			t.Setenv("HTTP_TRACE_REDACT", "false")
+			send(t, New())
+		})
+		if !strings.Contains(out, "header-secret") {
+			t.Fatalf("expected unredacted dump, got:\n%s", out)
+		}
+	})
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	func() {
+		defer func() { os.Stdout = stdout }()
+		fn()
+	}()
+	_ = w.Close()
+	return <-done
+}
+
+func TestDumpOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("boom"))
+		case "/bad-json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{not json"))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := New(DumpOnError(&buf))
+	if _, err := Get[string](c, srv.URL+"/ok", Header("Authorization", "Bearer secret")); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("successful call dumped:\n%s", buf.String())
+	}
+
+	if _, err := Get[string](c, srv.URL+"/fail", Header("Authorization", "Bearer secret")); err == nil {
+		t.Fatalf("expected HTTP error")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "GET /fail") || !strings.Contains(out, "500 Internal Server Error") || !strings.Contains(out, "boom") {
+		t.Fatalf("expected full exchange, got:\n%s", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("secret leaked into dump:\n%s", out)
+	}
+
+	buf.Reset()
+	if _, err := Get[map[string]any](c, srv.URL+"/bad-json"); err == nil {
+		t.Fatalf("expected decode error")
+	}
+	if !strings.Contains(buf.String(), "{not json") {
+		t.Fatalf("decode error not dumped:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := Get[string](c, "http://127.0.0.1:1/down"); err == nil {
+		t.Fatalf("expected transport error")
+	}
+	if !strings.Contains(buf.String(), "[httpx: GET http://127.0.0.1:1/down: ") {
+		t.Fatalf("transport error not dumped:\n%s", buf.String())
+	}
+}
+
+func TestDumpOnErrorSampleAndLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := New(DumpOnError(&buf).DumpOnErrorSample(1))
+	c.dumpOnError.limit = 100
+	if _, err := Get[string](c, srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "[httpx: GET "+srv.URL+": sampled]\nGET / HTTP/1.1") || !strings.Contains(out, "bytes of dump omitted") {
+		t.Fatalf("expected sampled, truncated dump, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if _, err := Get[string](New(DumpOnError(&buf).DumpOnErrorSample(0)), srv.URL); err != nil || buf.Len() != 0 {
+		t.Fatalf("unsampled success dumped: %v\n%s", err, buf.String())
+	}
+}
+
+func TestDumpOnErrorSampleBeforeDumpOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	if _, err := Get[string](New(DumpOnErrorSample(1).DumpOnError(&buf)), srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "[httpx: GET "+srv.URL+": sampled]") {
+		t.Fatalf("expected sampled dump, got:\n%s", buf.String())
+	}
+	if _, err := Get[string](New(DumpOnErrorSample(1)), srv.URL); err != nil {
+		t.Fatalf("request without DumpOnError failed: %v", err)
+	}
+}
+
+func TestDumpOnErrorKeepsRequestDump(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var onError, perRequest bytes.Buffer
+	c := New(DumpOnError(&onError))
+	if _, err := Get[string](c, srv.URL+"/fail", DumpTo(&perRequest)); err == nil {
+		t.Fatalf("expected HTTP error")
+	}
+	for name, buf := range map[string]*bytes.Buffer{"DumpOnError": &onError, "DumpTo": &perRequest} {
+		if !strings.Contains(buf.String(), "GET /fail") || !strings.Contains(buf.String(), "500 Internal Server Error") {
+			t.Fatalf("%s: expected full exchange, got:\n%s", name, buf.String())
+		}
+	}
+}
+
+func TestDumpOnErrorWithEnableDump(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var onError bytes.Buffer
+	c := New(DumpOnError(&onError))
+	stdout := captureStdout(t, func() {
+		_, err := Get[string](c, srv.URL+"/fail", Header("Authorization", "Bearer secret"), EnableDump())
+		if err == nil {
+			t.Errorf("expected HTTP error")
+		}
+	})
+	if stdout != "" {
+		t.Fatalf("EnableDump wrote to stdout:\n%s", stdout)
+	}
+	out := onError.String()
+	if !strings.Contains(out, "GET /fail") || !strings.Contains(out, "500 Internal Server Error") {
+		t.Fatalf("expected full exchange, got:\n%s", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("secret leaked into dump:\n%s", out)
+	}
+}
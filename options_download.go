@@ -1,6 +1,41 @@
 package httpx
 
-import "github.com/imroc/req/v3"
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// minDownloadSegment keeps segmented downloads from splitting small files into
+// ranges that cost more in round trips than they gain in throughput.
+const minDownloadSegment = 64 << 10
+
+// resumeSuffix names the file that records how to resume a partial download.
+const resumeSuffix = ".resume"
+
+// partSuffix names the file an atomic Download writes to until it completes.
+const partSuffix = ".part"
+
+// errRangeIgnored reports a segment answered with the whole body instead of
+// the requested range.
+var errRangeIgnored = errors.New("httpx: server ignored range request")
 
 // OutputFile streams the response body to a file path.
 // @group Download Options
@@ -18,6 +53,1028 @@ func OutputFile(path string) OptionBuilder {
 
 func (b OptionBuilder) OutputFile(path string) OptionBuilder {
 	return b.add(requestOnly(func(r *req.Request) {
+		c := boundClient(r, nil)
+		if c != nil {
+			c.ownBodies()
+		}
+		if c != nil && c.download.atomicWrites() {
+			outputFileAtomic(r, path, c.download)
+			return
+		}
 		r.SetOutputFile(path)
 	}))
 }
+
+// AtomicDownload makes OutputFile and Download write to a temporary file in
+// the destination directory, fsync it and rename it into place only once the
+// transfer succeeds, so the destination never holds a partial or failed body.
+// OutputFile removes the temporary file on failure; Download keeps it as
+// dest+".part" so the next call can resume.
+// @group Download Options
+//
+// Applies to client configuration only.
+// Example: never leave a half-written file behind
+//
+//	c := httpx.New(httpx.AtomicDownload())
+//	_, err := httpx.Get[[]byte](c, "https://httpbin.org/bytes/1024", httpx.OutputFile("/tmp/file.bin"))
+//	fmt.Println(err == nil)
+//	// true
+func AtomicDownload() OptionBuilder {
+	return OptionBuilder{}.AtomicDownload()
+}
+
+func (b OptionBuilder) AtomicDownload() OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.download.clone()
+		cfg.atomic = true
+		c.download = cfg
+	}))
+}
+
+// ExpectChecksum verifies files written by OutputFile and Download against a
+// hex-encoded sha-256 or sha-512 sum and implies AtomicDownload. A mismatch
+// is reported as a *ChecksumError and the file is not put in place. An
+// invalid sum is reported as an error on every request.
+// @group Download Options
+//
+// Applies to client configuration only.
+// Example: verify a release archive
+//
+//	c := httpx.New(httpx.Timeout(0))
+//	err := httpx.Download(context.Background(), c, "https://example.com/app.tar.gz", "/tmp/app.tar.gz",
+//		httpx.ExpectChecksum(httpx.DigestSHA256, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"),
+//	)
+//	var sumErr *httpx.ChecksumError
+//	fmt.Println(errors.As(err, &sumErr))
+//	// false
+func ExpectChecksum(alg DigestAlgorithm, sum string) OptionBuilder {
+	return OptionBuilder{}.ExpectChecksum(alg, sum)
+}
+
+func (b OptionBuilder) ExpectChecksum(alg DigestAlgorithm, sum string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		want, err := hex.DecodeString(sum)
+		if err == nil {
+			_, err = newDigestHash(alg)
+		}
+		if err != nil {
+			failRequests(c, fmt.Errorf("httpx: expected checksum: %w", err))
+			return
+		}
+		cfg := c.download.clone()
+		cfg.checksums = append(cfg.checksums, checksum{alg: alg, sum: want})
+		c.download = cfg
+	}))
+}
+
+// VerifyDigestHeader verifies files written by OutputFile and Download against
+// the RFC 9530 Repr-Digest response header, or Content-Digest when the whole
+// file arrived in one plain response, and implies AtomicDownload. A mismatch
+// is reported as a *ChecksumError; a response without a usable sha-256 or
+// sha-512 digest fails the download.
+// @group Download Options
+//
+// Applies to client configuration only.
+// Example: trust the server's digest
+//
+//	c := httpx.New(httpx.VerifyDigestHeader())
+//	_, err := httpx.Get[[]byte](c, "https://example.com/data.bin", httpx.OutputFile("/tmp/data.bin"))
+//	_ = err
+func VerifyDigestHeader() OptionBuilder {
+	return OptionBuilder{}.VerifyDigestHeader()
+}
+
+func (b OptionBuilder) VerifyDigestHeader() OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.download.clone()
+		cfg.verifyDigest = true
+		c.download = cfg
+	}))
+}
+
+// ChecksumError reports a downloaded file whose digest does not match the
+// expected sum or the server's digest header. The file is not left at Path.
+// @group Download Options
+type ChecksumError struct {
+	// Path is the destination the file was meant for.
+	Path string
+	// Algorithm is the digest that failed to match.
+	Algorithm DigestAlgorithm
+	// Expected and Actual are the hex-encoded digests.
+	Expected string
+	Actual   string
+}
+
+// Error implements error.
+// @group Download Options
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("httpx: %s checksum mismatch for %s: expected %s, got %s", e.Algorithm, e.Path, e.Expected, e.Actual)
+}
+
+// DownloadInfo describes the progress of a download.
+// @group Download Options
+type DownloadInfo struct {
+	// DownloadedSize counts the bytes received so far, including bytes kept
+	// from an earlier partial download.
+	DownloadedSize int64
+	// TotalSize is the size of the complete body, or 0 when unknown.
+	TotalSize int64
+	// Rate is the average transfer rate in bytes per second since the
+	// transfer started.
+	Rate float64
+	// ETA estimates the time left, or 0 when the total size is unknown.
+	ETA time.Duration
+}
+
+// Download fetches url into dest and can be called again to continue an
+// interrupted transfer. While a download is incomplete, dest sits next to a
+// dest+".resume" file holding the resource's validator; the next call sends
+// Range and If-Range so the server returns only the missing bytes, or the
+// whole file if it changed. A resource without a strong ETag or Last-Modified
+// is downloaded again from the start. An existing dest without a resume file
+// is replaced. With DownloadSegments, the file is fetched as concurrent byte
+// ranges when the server advertises Accept-Ranges, and as a single stream
+// otherwise. Header, auth and other request options apply to every request;
+// the client timeout bounds each request, so use Timeout(0) for large files.
+// @group Download Options
+//
+// Example: resumable download
+//
+//	c := httpx.New(httpx.Timeout(0))
+//	err := httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso")
+//	fmt.Println(err == nil)
+//	// true
+//
+// Example: parallel segments with progress
+//
+//	_ = httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso",
+//		httpx.DownloadSegments(4).
+//			DownloadCallback(func(info httpx.DownloadInfo) {
+//				fmt.Printf("\r%d/%d", info.DownloadedSize, info.TotalSize)
+//			}),
+//	)
+func Download(ctx context.Context, client *Client, url, dest string, opts ...Option) error {
+	if client == nil {
+		client = Default()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client = client.clone()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.applyClient(client)
+	}
+	client.ownBodies()
+	d := &downloader{
+		ctx:      ctx,
+		client:   client,
+		url:      url,
+		dest:     dest,
+		path:     dest,
+		opts:     opts,
+		segments: client.download.segmentCount(),
+		progress: &downloadProgress{id: progressIDs.Add(1)},
+	}
+	if client.download.atomicWrites() {
+		d.path = dest + partSuffix
+	}
+	return d.run()
+}
+
+// DownloadSegments splits Download into n concurrent byte-range requests when
+// the server advertises Accept-Ranges and reports the size. Files too small to
+// benefit use fewer segments; other servers are read as a single stream.
+// @group Download Options
+//
+// Applies to Download only.
+// Example: four parallel connections
+//
+//	c := httpx.New(httpx.Timeout(0))
+//	_ = httpx.Download(context.Background(), c, "https://example.com/big.iso", "/tmp/big.iso", httpx.DownloadSegments(4))
+func DownloadSegments(n int) OptionBuilder {
+	return OptionBuilder{}.DownloadSegments(n)
+}
+
+func (b OptionBuilder) DownloadSegments(n int) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.download.clone()
+		cfg.segments = n
+		c.download = cfg
+	}))
+}
+
+// DownloadCallback registers a callback for download progress. It reports
+// bodies saved with OutputFile, bodies decoded in memory and Download, where
+// segments report into one running total. Events arrive at most every 200ms,
+// and a final 100% event is always delivered once the body has been read.
+// @group Download Options
+//
+// Applies to individual requests only.
+// Example: track download progress
+//
+//	c := httpx.New()
+//	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576",
+//		httpx.OutputFile("/tmp/file.bin"),
+//		httpx.DownloadCallback(func(info httpx.DownloadInfo) {
+//			fmt.Printf("\r%d/%d bytes, %.0f B/s, ETA %s", info.DownloadedSize, info.TotalSize, info.Rate, info.ETA)
+//			if info.TotalSize > 0 && info.DownloadedSize >= info.TotalSize {
+//				fmt.Print("\n")
+//			}
+//		}),
+//	)
+func DownloadCallback(callback func(DownloadInfo)) OptionBuilder {
+	return OptionBuilder{}.DownloadCallback(callback)
+}
+
+func (b OptionBuilder) DownloadCallback(callback func(DownloadInfo)) OptionBuilder {
+	return b.DownloadCallbackWithInterval(callback, 200*time.Millisecond)
+}
+
+// DownloadCallbackWithInterval registers a callback for download progress
+// that fires at most once per minInterval. The final 100% event is always
+// delivered.
+// @group Download Options
+//
+// Applies to individual requests only.
+// Example: throttle download progress updates
+//
+//	c := httpx.New()
+//	res, _ := httpx.Get[map[string]any](c, "https://httpbin.org/get",
+//		httpx.DownloadCallbackWithInterval(func(info httpx.DownloadInfo) {
+//			fmt.Printf("\rprogress: %d bytes", info.DownloadedSize)
+//		}, 200*time.Millisecond),
+//	)
+//	httpx.Dump(res) // dumps map[string]any
+//	// #map[string]interface {} {
+//	//   url => "https://httpbin.org/get" #string
+//	// }
+func DownloadCallbackWithInterval(callback func(DownloadInfo), minInterval time.Duration) OptionBuilder {
+	return OptionBuilder{}.DownloadCallbackWithInterval(callback, minInterval)
+}
+
+func (b OptionBuilder) DownloadCallbackWithInterval(callback func(DownloadInfo), minInterval time.Duration) OptionBuilder {
+	if callback == nil {
+		return b
+	}
+	return b.add(requestOnly(func(r *req.Request) {
+		watchDownload(r, callback, minInterval)
+	}))
+}
+
+// DownloadProgress shows download progress with the transfer rate and
+// estimated time left. Output goes to the renderer set with ProgressTo, or
+// else to stdout, as bars on a terminal and as log lines otherwise.
+// @group Download Options
+// Applies to individual requests only.
+//
+// Example: download with automatic progress
+//
+//	c := httpx.New()
+//	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576",
+//		httpx.OutputFile("/tmp/file.bin"),
+//		httpx.DownloadProgress(),
+//	)
+func DownloadProgress() OptionBuilder {
+	return OptionBuilder{}.DownloadProgress()
+}
+
+func (b OptionBuilder) DownloadProgress() OptionBuilder {
+	return b.add(requestOnly(func(r *req.Request) {
+		tracker := &progressTracker{renderer: progressRendererFor(r)}
+		id := progressID(r, downloadProgressKey{})
+		tracker.endOnError(r)
+		watchDownload(r, func(info DownloadInfo) {
+			tracker.render(ProgressEvent{
+				ID:          id,
+				Label:       progressLabel("download", r),
+				Transferred: info.DownloadedSize,
+				Total:       info.TotalSize,
+				Rate:        info.Rate,
+				ETA:         info.ETA,
+				Done:        info.TotalSize > 0 && info.DownloadedSize >= info.TotalSize,
+			})
+		}, 200*time.Millisecond)
+	}))
+}
+
+// downloadConfig is shared by clients cloned from each other, so options copy
+// it rather than modify it.
+type downloadConfig struct {
+	segments     int
+	atomic       bool
+	checksums    []checksum
+	verifyDigest bool
+	collision    CollisionPolicy
+	outputDir    string
+	// outputDirHooked is set once the client applies outputDir to requests.
+	outputDirHooked bool
+	// watching is set once the transport counts response bodies for
+	// DownloadCallback.
+	watching bool
+}
+
+type checksum struct {
+	alg DigestAlgorithm
+	sum []byte
+}
+
+func (d *downloadConfig) clone() *downloadConfig {
+	if d == nil {
+		return &downloadConfig{}
+	}
+	cp := *d
+	cp.checksums = slices.Clip(d.checksums)
+	return &cp
+}
+
+func (d *downloadConfig) atomicWrites() bool {
+	return d != nil && (d.atomic || d.verifying())
+}
+
+func (d *downloadConfig) verifying() bool {
+	return d != nil && (len(d.checksums) > 0 || d.verifyDigest)
+}
+
+func (d *downloadConfig) segmentCount() int {
+	if d == nil || d.segments < 1 {
+		return 1
+	}
+	return d.segments
+}
+
+// resumeState is persisted next to an incomplete download.
+type resumeState struct {
+	URL       string `json:"url"`
+	Validator string `json:"validator,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	// Segmented is set while segments are being written out of order. A file
+	// left in that state by a crash has holes, so it cannot be resumed.
+	Segmented bool `json:"segmented,omitempty"`
+}
+
+type downloader struct {
+	ctx    context.Context
+	client *Client
+	url    string
+	dest   string
+	// path receives the bytes: dest itself, or dest+".part" for atomic
+	// downloads.
+	path     string
+	opts     []Option
+	segments int
+	progress *downloadProgress
+
+	mu      sync.Mutex
+	digests http.Header
+}
+
+func (d *downloader) run() error {
+	offset, state := d.resumePoint()
+	if d.segments > 1 {
+		done, err := d.runSegmented(offset, state)
+		if done || err != nil {
+			return err
+		}
+		offset, state = d.resumePoint()
+	}
+	return d.runSingle(offset, state)
+}
+
+// resumePoint returns how many bytes of dest can be kept and what is known
+// about them.
+func (d *downloader) resumePoint() (int64, resumeState) {
+	state, ok := d.loadState()
+	// Without a validator a changed resource cannot be detected, so the
+	// kept bytes might not belong to it.
+	if !ok || state.URL != d.url || state.Segmented || state.Validator == "" {
+		return 0, resumeState{}
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return 0, resumeState{}
+	}
+	return info.Size(), state
+}
+
+func (d *downloader) runSingle(offset int64, state resumeState) error {
+	r := d.request(d.ctx)
+	if offset > 0 {
+		r.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+		if state.Validator != "" {
+			r.SetHeader("If-Range", state.Validator)
+		}
+	}
+	resp, err := d.send(r, methodGet)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	start, total := int64(0), resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		first, _, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || first != offset {
+			if offset == 0 {
+				return fmt.Errorf("httpx: unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+			}
+			_ = resp.Body.Close()
+			return d.runSingle(0, resumeState{})
+		}
+		start, total = first, size
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset == 0 {
+			_, _ = resp.ToBytes()
+			return d.client.mapError(resp)
+		}
+		_, _, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		_ = resp.Body.Close()
+		if ok && size == offset {
+			d.progress.reset(offset, size)
+			return d.complete()
+		}
+		return d.runSingle(0, resumeState{})
+	default:
+		if !resp.IsSuccessState() {
+			_, _ = resp.ToBytes()
+			return d.client.mapError(resp)
+		}
+	}
+	if total < 0 {
+		total = 0
+	}
+	d.noteDigests(resp.Response, start == 0 && resp.StatusCode == http.StatusOK)
+
+	if err := d.saveState(resumeState{URL: d.url, Validator: validatorOf(resp.Header), Size: total}); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(start); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		_ = f.Close()
+		return err
+	}
+	d.progress.reset(start, total)
+	written, err := io.Copy(&progressWriter{w: f, progress: d.progress}, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if total > 0 && start+written != total {
+		return io.ErrUnexpectedEOF
+	}
+	return d.complete()
+}
+
+// runSegmented downloads the rest of the file as concurrent ranges. It
+// reports done=false without error when the file should be read as a single
+// stream instead.
+func (d *downloader) runSegmented(offset int64, state resumeState) (bool, error) {
+	head, err := d.send(d.request(d.ctx), methodHead)
+	if err != nil || !head.IsSuccessState() {
+		return false, nil
+	}
+	total := head.ContentLength
+	validator := validatorOf(head.Header)
+	d.noteDigests(head.Response, false)
+	if !strings.EqualFold(head.Header.Get("Accept-Ranges"), "bytes") || total <= 0 {
+		return false, nil
+	}
+	if offset > total || (state.Validator != "" && state.Validator != validator) {
+		offset = 0
+	}
+	count := min(int64(d.segments), (total-offset)/minDownloadSegment)
+	if count < 2 {
+		return false, nil
+	}
+
+	if err := d.saveState(resumeState{URL: d.url, Validator: validator, Size: total, Segmented: true}); err != nil {
+		return true, err
+	}
+	f, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return true, err
+	}
+	if err := f.Truncate(offset); err != nil {
+		_ = f.Close()
+		return true, err
+	}
+	d.progress.reset(offset, total)
+
+	size := (total - offset) / count
+	segs := make([]*downloadSegment, count)
+	for i := range segs {
+		first := offset + int64(i)*size
+		last := first + size - 1
+		if i == len(segs)-1 {
+			last = total - 1
+		}
+		segs[i] = &downloadSegment{first: first, last: last}
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, seg := range segs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.fetchSegment(ctx, f, seg, validator); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		if err := f.Close(); err != nil {
+			return true, err
+		}
+		return true, d.complete()
+	}
+
+	// Keep the contiguous prefix so the next call resumes from a file
+	// without holes.
+	kept := offset
+	for _, seg := range segs {
+		kept += seg.written
+		if seg.written < seg.last-seg.first+1 {
+			break
+		}
+	}
+	if errors.Is(firstErr, errRangeIgnored) {
+		// The server cannot serve ranges after all, or the file changed.
+		_ = f.Close()
+		return false, d.removeState()
+	}
+	truncErr := f.Truncate(kept)
+	_ = f.Close()
+	if truncErr == nil {
+		_ = d.saveState(resumeState{URL: d.url, Validator: validator, Size: total})
+	}
+	return true, firstErr
+}
+
+type downloadSegment struct {
+	first, last int64
+	written     int64
+}
+
+func (d *downloader) fetchSegment(ctx context.Context, f *os.File, seg *downloadSegment, validator string) error {
+	r := d.request(ctx)
+	r.SetHeader("Range", fmt.Sprintf("bytes=%d-%d", seg.first, seg.last))
+	if validator != "" {
+		r.SetHeader("If-Range", validator)
+	}
+	resp, err := d.send(r, methodGet)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return errRangeIgnored
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		_, _ = resp.ToBytes()
+		return d.client.mapError(resp)
+	}
+	if first, _, _, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || first != seg.first {
+		return errRangeIgnored
+	}
+	d.noteDigests(resp.Response, false)
+	w := &progressWriter{w: io.NewOffsetWriter(f, seg.first), progress: d.progress, written: &seg.written}
+	want := seg.last - seg.first + 1
+	if _, err := io.Copy(w, io.LimitReader(resp.Body, want)); err != nil {
+		return err
+	}
+	if seg.written != want {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// complete verifies a finished download, moves it into place and delivers
+// the final progress event. A file that fails verification is discarded.
+func (d *downloader) complete() error {
+	if d.path != d.dest {
+		d.mu.Lock()
+		digests := d.digests
+		d.mu.Unlock()
+		err := d.client.download.verify(d.path, d.dest, digests)
+		if err == nil {
+			err = commitFile(d.path, d.dest)
+		}
+		if err != nil {
+			var sumErr *ChecksumError
+			if errors.As(err, &sumErr) {
+				_ = os.Remove(d.path)
+				_ = d.removeState()
+			}
+			return err
+		}
+	}
+	d.progress.finish()
+	return d.removeState()
+}
+
+// noteDigests keeps the first digest headers seen across the responses of a
+// download.
+func (d *downloader) noteDigests(resp *http.Response, whole bool) {
+	h := digestHeaders(resp, whole)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.digests) == 0 {
+		d.digests = h
+	}
+}
+
+func (d *downloader) request(ctx context.Context) *req.Request {
+	r := d.client.req.R()
+	r.SetContext(context.WithValue(ctx, downloadProgressKey{}, d.progress))
+	bindClient(r, d.client)
+	for _, opt := range d.opts {
+		if opt == nil {
+			continue
+		}
+		opt.applyRequest(r)
+	}
+	r.DisableAutoReadResponse()
+	return r
+}
+
+func (d *downloader) send(r *req.Request, method string) (*req.Response, error) {
+	finish := d.client.startCall(r, method, d.url)
+	resp, err := send(r, method, d.url)
+	finish(resp, err)
+	return resp, err
+}
+
+func (d *downloader) loadState() (resumeState, bool) {
+	var state resumeState
+	data, err := os.ReadFile(d.dest + resumeSuffix)
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		return resumeState{}, false
+	}
+	return state, true
+}
+
+func (d *downloader) saveState(state resumeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.dest+resumeSuffix, data, 0o644)
+}
+
+func (d *downloader) removeState() error {
+	if err := os.Remove(d.dest + resumeSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// outputFileAtomic saves the response to a temporary file next to path and
+// renames it into place after a successful, verified attempt.
+func outputFileAtomic(r *req.Request, path string, cfg *downloadConfig) {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"."+rand.Text()+".tmp")
+	r.SetOutputFile(tmp)
+	r.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		if resp.Err != nil || resp.Response == nil || !resp.IsSuccessState() {
+			_ = os.Remove(tmp)
+			return nil
+		}
+		err := cfg.verify(tmp, path, digestHeaders(resp.Response, resp.StatusCode == http.StatusOK))
+		if err == nil {
+			err = commitFile(tmp, path)
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+		return err
+	})
+}
+
+// verify syncs the file at path and checks it against the expected sums and
+// digest headers. dest names the file in a *ChecksumError.
+func (d *downloadConfig) verify(path, dest string, digests http.Header) error {
+	var checks []checksum
+	if d != nil {
+		checks = append(checks, d.checksums...)
+		if d.verifyDigest {
+			fromHeader, err := headerChecksums(digests)
+			if err != nil {
+				return err
+			}
+			checks = append(checks, fromHeader...)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	hashes := map[DigestAlgorithm]hash.Hash{}
+	var writers []io.Writer
+	for _, c := range checks {
+		if _, ok := hashes[c.alg]; !ok {
+			h, _ := newDigestHash(c.alg)
+			hashes[c.alg] = h
+			writers = append(writers, h)
+		}
+	}
+	if len(writers) > 0 {
+		_, err = io.Copy(io.MultiWriter(writers...), f)
+	}
+	if syncErr := f.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range checks {
+		if got := hashes[c.alg].Sum(nil); !bytes.Equal(got, c.sum) {
+			return &ChecksumError{
+				Path:      dest,
+				Algorithm: c.alg,
+				Expected:  hex.EncodeToString(c.sum),
+				Actual:    hex.EncodeToString(got),
+			}
+		}
+	}
+	return nil
+}
+
+// digestHeaders returns the digest headers of resp that describe the saved
+// file. Content-Digest only does when the whole file came in this response,
+// and neither does once the transport has decoded the body.
+func digestHeaders(resp *http.Response, whole bool) http.Header {
+	h := http.Header{}
+	if resp == nil || resp.Uncompressed {
+		return h
+	}
+	if v := resp.Header.Get("Repr-Digest"); v != "" {
+		h.Set("Repr-Digest", v)
+	}
+	if v := resp.Header.Get("Content-Digest"); v != "" && whole {
+		h.Set("Content-Digest", v)
+	}
+	return h
+}
+
+// headerChecksums extracts the supported digests from Repr-Digest, or else
+// Content-Digest.
+func headerChecksums(h http.Header) ([]checksum, error) {
+	for _, name := range []string{"Repr-Digest", "Content-Digest"} {
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		digests, err := parseSFDictionary(value)
+		if err != nil {
+			return nil, fmt.Errorf("httpx: parse %s: %w", name, err)
+		}
+		var out []checksum
+		for alg, member := range digests {
+			if _, err := newDigestHash(DigestAlgorithm(alg)); err != nil || member.item == nil || !member.item.binary {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(member.item.value)
+			if err != nil {
+				return nil, fmt.Errorf("httpx: %s %s: %w", name, alg, err)
+			}
+			out = append(out, checksum{alg: DigestAlgorithm(alg), sum: sum})
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	return nil, errors.New("httpx: response has no supported Repr-Digest or Content-Digest")
+}
+
+// commitFile renames tmp over dest and syncs the directory so the rename
+// survives a crash.
+func commitFile(tmp, dest string) error {
+	if err := os.Rename(tmp, dest); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(dest)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
+
+// validatorOf returns the value to send in If-Range: a strong ETag, or else
+// Last-Modified. Weak ETags cannot be used for range requests.
+func validatorOf(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// parseContentRange parses "bytes first-last/size" and "bytes */size". An
+// unknown size is returned as 0 and an unsatisfied range as first = last = -1.
+func parseContentRange(v string) (first, last, size int64, ok bool) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(v), "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, sz, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	if sz != "*" {
+		n, err := strconv.ParseInt(sz, 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		size = n
+	}
+	if rng == "*" {
+		return -1, -1, size, true
+	}
+	a, b, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	first, err1 := strconv.ParseInt(a, 10, 64)
+	last, err2 := strconv.ParseInt(b, 10, 64)
+	if err1 != nil || err2 != nil || last < first {
+		return 0, 0, 0, false
+	}
+	return first, last, size, true
+}
+
+type downloadProgressKey struct{}
+
+// watchDownload reports the progress of r's response body to callback. Requests
+// made by Download carry their own progress, which counts written bytes across
+// segments; other requests count the body as it is read from the transport.
+func watchDownload(r *req.Request, callback func(DownloadInfo), interval time.Duration) {
+	if p, ok := r.Context().Value(downloadProgressKey{}).(*downloadProgress); ok {
+		p.watch(callback, interval)
+		return
+	}
+	c := boundClient(r, nil)
+	if c == nil {
+		return
+	}
+	c.watchDownloads()
+	p := &downloadProgress{}
+	p.watch(callback, interval)
+	r.SetContext(context.WithValue(r.Context(), downloadProgressKey{}, p))
+	r.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		if resp.Err == nil {
+			p.finish()
+		}
+		return nil
+	})
+}
+
+// watchDownloads counts response bodies of requests that carry a
+// downloadProgress. It is installed on the per-request clone of the client.
+func (c *Client) watchDownloads() {
+	if c.download != nil && c.download.watching {
+		return
+	}
+	cfg := c.download.clone()
+	cfg.watching = true
+	c.download = cfg
+	c.req.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			resp, err := rt.RoundTrip(r)
+			if p, ok := r.Context().Value(downloadProgressKey{}).(*downloadProgress); ok && resp != nil && resp.Body != nil {
+				// Each attempt starts over, so retries do not add up.
+				p.reset(0, max(resp.ContentLength, 0))
+				resp.Body = &progressReader{ReadCloser: resp.Body, progress: p}
+			}
+			return resp, err
+		}
+	})
+}
+
+// downloadProgress aggregates the bytes of a transfer, across all segments of
+// a Download, and reports them to a callback.
+type downloadProgress struct {
+	mu       sync.Mutex
+	id       int64
+	info     DownloadInfo
+	callback func(DownloadInfo)
+	interval time.Duration
+	started  time.Time
+	base     int64
+	last     time.Time
+	reported bool
+}
+
+func (p *downloadProgress) progressID() int64 {
+	return p.id
+}
+
+func (p *downloadProgress) watch(callback func(DownloadInfo), interval time.Duration) {
+	p.mu.Lock()
+	p.callback = callback
+	p.interval = interval
+	p.mu.Unlock()
+}
+
+// reset starts a transfer of total bytes, downloaded of which are already
+// present. They do not count toward the rate.
+func (p *downloadProgress) reset(downloaded, total int64) {
+	p.mu.Lock()
+	p.info = DownloadInfo{DownloadedSize: downloaded, TotalSize: total}
+	p.started = time.Now()
+	p.base = downloaded
+	p.last = time.Time{}
+	p.reported = false
+	p.mu.Unlock()
+}
+
+func (p *downloadProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.info.DownloadedSize += n
+	if p.callback == nil {
+		return
+	}
+	done := p.info.TotalSize > 0 && p.info.DownloadedSize >= p.info.TotalSize
+	now := time.Now()
+	if !done && p.interval > 0 && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	p.reported = done
+	p.callback(p.snapshot(now))
+}
+
+// finish delivers the 100% event unless the last read already did.
+func (p *downloadProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.callback == nil || p.reported {
+		return
+	}
+	if p.info.TotalSize < p.info.DownloadedSize {
+		p.info.TotalSize = p.info.DownloadedSize
+	}
+	p.info.DownloadedSize = p.info.TotalSize
+	p.reported = true
+	p.callback(p.snapshot(time.Now()))
+}
+
+// snapshot fills in the rate and ETA. p.mu must be held.
+func (p *downloadProgress) snapshot(now time.Time) DownloadInfo {
+	info := p.info
+	info.Rate, info.ETA = transferRate(p.started, now, info.DownloadedSize-p.base, info.DownloadedSize, info.TotalSize)
+	return info
+}
+
+type progressReader struct {
+	io.ReadCloser
+	progress *downloadProgress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.progress.add(int64(n))
+	}
+	return n, err
+}
+
+type progressWriter struct {
+	w        io.Writer
+	progress *downloadProgress
+	written  *int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		if w.written != nil {
+			*w.written += int64(n)
+		}
+		w.progress.add(int64(n))
+	}
+	return n, err
+}
@@ -1,11 +1,24 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
 )
 
 func TestOutputFile(t *testing.T) {
@@ -29,3 +42,480 @@ func TestOutputFile(t *testing.T) {
 		t.Fatalf("output = %q", string(data))
 	}
 }
+
+// rangeServer serves content with ETag and range support and records the
+// Range and If-Range headers of every GET.
+type rangeServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	ranges  []string
+	ifRange []string
+	// cutAfter, when positive, drops the connection of the next GET after
+	// that many bytes.
+	cutAfter int
+}
+
+func newRangeServer(t *testing.T, content []byte) *rangeServer {
+	t.Helper()
+	s := &rangeServer{content: content, etag: `"v1"`}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		content, etag, cut := s.content, s.etag, s.cutAfter
+		if r.Method == http.MethodGet {
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+			s.ifRange = append(s.ifRange, r.Header.Get("If-Range"))
+			s.cutAfter = 0
+		}
+		s.mu.Unlock()
+		w.Header().Set("ETag", etag)
+		if cut > 0 {
+			w.Header().Set("Content-Length", "999999999")
+			_, _ = w.Write(content[:cut])
+			return
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.New(rand.NewSource(1)).Read(b)
+	return b
+}
+
+func TestDownload(t *testing.T) {
+	content := randomBytes(1000)
+	srv := newRangeServer(t, content)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(dest, []byte("stale file without resume state"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Download(context.Background(), New(), srv.URL, dest); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, content)
+	if srv.ranges[0] != "" {
+		t.Fatalf("fresh download sent Range %q", srv.ranges[0])
+	}
+	if _, err := os.Stat(dest + resumeSuffix); !os.IsNotExist(err) {
+		t.Fatalf("resume file left behind: %v", err)
+	}
+}
+
+func TestDownloadResumes(t *testing.T) {
+	content := randomBytes(1000)
+	srv := newRangeServer(t, content)
+	srv.cutAfter = 400
+	dest := filepath.Join(t.TempDir(), "file.bin")
+
+	if err := Download(context.Background(), New(), srv.URL, dest); err == nil {
+		t.Fatalf("expected interrupted download to fail")
+	}
+	assertFile(t, dest, content[:400])
+
+	var events []DownloadInfo
+	err := Download(context.Background(), New(), srv.URL, dest, DownloadCallback(func(info DownloadInfo) {
+		events = append(events, info)
+	}))
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	assertFile(t, dest, content)
+	if srv.ranges[1] != "bytes=400-" || srv.ifRange[1] != `"v1"` {
+		t.Fatalf("resume sent Range %q If-Range %q", srv.ranges[1], srv.ifRange[1])
+	}
+	last := events[len(events)-1]
+	if events[0].DownloadedSize <= 400 || last.DownloadedSize != 1000 || last.TotalSize != 1000 {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestDownloadRestartsWhenResourceChanged(t *testing.T) {
+	srv := newRangeServer(t, randomBytes(1000))
+	srv.cutAfter = 400
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	_ = Download(context.Background(), New(), srv.URL, dest)
+
+	updated := []byte(strings.Repeat("new", 200))
+	srv.mu.Lock()
+	srv.content, srv.etag = updated, `"v2"`
+	srv.mu.Unlock()
+
+	if err := Download(context.Background(), New(), srv.URL, dest); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, updated)
+}
+
+func TestDownloadRestartsWithoutValidator(t *testing.T) {
+	srv := newRangeServer(t, randomBytes(1000))
+	srv.etag = `W/"v1"`
+	srv.cutAfter = 400
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	_ = Download(context.Background(), New(), srv.URL, dest)
+
+	updated := []byte(strings.Repeat("new", 200))
+	srv.mu.Lock()
+	srv.content = updated
+	srv.mu.Unlock()
+
+	if err := Download(context.Background(), New(), srv.URL, dest); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, updated)
+	if srv.ranges[1] != "" {
+		t.Fatalf("download without validator sent Range %q", srv.ranges[1])
+	}
+}
+
+func TestDownloadSegments(t *testing.T) {
+	content := randomBytes(4*minDownloadSegment + 123)
+	srv := newRangeServer(t, content)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+
+	var mu sync.Mutex
+	var last DownloadInfo
+	err := Download(context.Background(), New(), srv.URL, dest, DownloadSegments(4).DownloadCallback(func(info DownloadInfo) {
+		mu.Lock()
+		last = info
+		mu.Unlock()
+	}))
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, content)
+	if len(srv.ranges) != 4 {
+		t.Fatalf("ranges = %q", srv.ranges)
+	}
+	for _, r := range srv.ranges {
+		if !strings.HasPrefix(r, "bytes=") {
+			t.Fatalf("segment without range: %q", srv.ranges)
+		}
+	}
+	if last.DownloadedSize != int64(len(content)) || last.TotalSize != int64(len(content)) {
+		t.Fatalf("last event = %+v", last)
+	}
+}
+
+func TestDownloadSegmentsWithoutRangeSupport(t *testing.T) {
+	content := randomBytes(4 * minDownloadSegment)
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+	dest := filepath.Join(t.TempDir(), "file.bin")
+
+	if err := Download(context.Background(), New(), srv.URL, dest, DownloadSegments(4)); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, content)
+	if gets != 1 {
+		t.Fatalf("gets = %d", gets)
+	}
+}
+
+func TestOutputFileAtomicKeepsDestinationOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(dest, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get[[]byte](New(AtomicDownload()), srv.URL, OutputFile(dest)); err == nil {
+		t.Fatalf("expected error")
+	}
+	assertFile(t, dest, []byte("previous"))
+	assertOnlyFile(t, dir, "out.txt")
+}
+
+func TestOutputFileExpectChecksum(t *testing.T) {
+	payload := []byte(`{"ok":true}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.txt")
+	sum := sha256.Sum256(payload)
+
+	_, err := Get[map[string]any](New(), srv.URL, ExpectChecksum(DigestSHA256, hex.EncodeToString(sum[:])), OutputFile(dest))
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, payload)
+
+	wrong := strings.Repeat("00", sha256.Size)
+	_, err = Get[map[string]any](New(), srv.URL, ExpectChecksum(DigestSHA256, wrong), OutputFile(filepath.Join(dir, "bad.txt")))
+	var sumErr *ChecksumError
+	if !errors.As(err, &sumErr) {
+		t.Fatalf("err = %v, want *ChecksumError", err)
+	}
+	if sumErr.Expected != wrong || sumErr.Actual != hex.EncodeToString(sum[:]) || sumErr.Algorithm != DigestSHA256 {
+		t.Fatalf("checksum error = %+v", sumErr)
+	}
+	assertOnlyFile(t, dir, "out.txt")
+}
+
+func TestExpectChecksumInvalid(t *testing.T) {
+	_, err := Get[string](New(), "http://127.0.0.1:1", ExpectChecksum(DigestSHA256, "zz"))
+	if err == nil || !strings.Contains(err.Error(), "expected checksum") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestOutputFileVerifyDigestHeader(t *testing.T) {
+	payload := []byte("hello")
+	digest, _ := contentDigest(payload, []DigestAlgorithm{DigestSHA512})
+	tampered := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Repr-Digest", digest)
+		if tampered {
+			_, _ = w.Write([]byte("hellO"))
+			return
+		}
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+	dest := filepath.Join(t.TempDir(), "out.txt")
+
+	if _, err := Get[[]byte](New(VerifyDigestHeader()), srv.URL, OutputFile(dest)); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	assertFile(t, dest, payload)
+
+	tampered = true
+	_, err := Get[[]byte](New(VerifyDigestHeader()), srv.URL, OutputFile(dest))
+	var sumErr *ChecksumError
+	if !errors.As(err, &sumErr) || sumErr.Algorithm != DigestSHA512 {
+		t.Fatalf("err = %v, want sha-512 *ChecksumError", err)
+	}
+	assertFile(t, dest, payload)
+}
+
+func TestVerifyDigestHeaderMissing(t *testing.T) {
+	srv := debugServer()
+	defer srv.Close()
+	dir := t.TempDir()
+
+	_, err := Get[[]byte](New(VerifyDigestHeader()), srv.URL, OutputFile(filepath.Join(dir, "out.txt")))
+	if err == nil || !strings.Contains(err.Error(), "Repr-Digest") {
+		t.Fatalf("err = %v", err)
+	}
+	assertOnlyFile(t, dir)
+}
+
+func TestDownloadAtomicResumesFromPartFile(t *testing.T) {
+	content := randomBytes(1000)
+	srv := newRangeServer(t, content)
+	srv.cutAfter = 400
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	sum := sha256.Sum256(content)
+	opt := ExpectChecksum(DigestSHA256, hex.EncodeToString(sum[:]))
+
+	if err := Download(context.Background(), New(), srv.URL, dest, opt); err == nil {
+		t.Fatalf("expected interrupted download to fail")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("partial download visible at destination: %v", err)
+	}
+	assertFile(t, dest+partSuffix, content[:400])
+
+	if err := Download(context.Background(), New(), srv.URL, dest, opt); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	assertFile(t, dest, content)
+	assertOnlyFile(t, filepath.Dir(dest), "file.bin")
+}
+
+func TestDownloadChecksumMismatchCleansUp(t *testing.T) {
+	srv := newRangeServer(t, randomBytes(4*minDownloadSegment))
+	dest := filepath.Join(t.TempDir(), "file.bin")
+
+	err := Download(context.Background(), New(), srv.URL, dest,
+		DownloadSegments(4).ExpectChecksum(DigestSHA512, strings.Repeat("ab", sha512.Size)))
+	var sumErr *ChecksumError
+	if !errors.As(err, &sumErr) || sumErr.Path != dest {
+		t.Fatalf("err = %v, want *ChecksumError for %s", err, dest)
+	}
+	assertOnlyFile(t, filepath.Dir(dest))
+}
+
+func TestDownloadCallbackInMemory(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 256<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+
+	var events []DownloadInfo
+	res, err := Get[[]byte](New(), srv.URL, DownloadCallback(func(info DownloadInfo) {
+		events = append(events, info)
+	}))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !bytes.Equal(res, payload) {
+		t.Fatalf("body has %d bytes", len(res))
+	}
+	if len(events) < 2 {
+		t.Fatalf("events = %+v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].DownloadedSize < events[i-1].DownloadedSize {
+			t.Fatalf("progress went backwards: %+v", events)
+		}
+	}
+	last := events[len(events)-1]
+	if last.DownloadedSize != int64(len(payload)) || last.TotalSize != int64(len(payload)) || last.ETA != 0 {
+		t.Fatalf("last event = %+v", last)
+	}
+	if last.Rate <= 0 {
+		t.Fatalf("rate = %v", last.Rate)
+	}
+}
+
+func TestDownloadCallbackWithIntervalOutputFile(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 256<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No Content-Length, so the size is unknown until the end.
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(payload)
+	}))
+	defer srv.Close()
+	dest := filepath.Join(t.TempDir(), "out.bin")
+
+	var events []DownloadInfo
+	_, err := Get[[]byte](New(), srv.URL, OutputFile(dest), DownloadCallbackWithInterval(func(info DownloadInfo) {
+		events = append(events, info)
+	}, time.Hour))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	assertFile(t, dest, payload)
+	if len(events) != 2 {
+		t.Fatalf("events = %+v", events)
+	}
+	if final := events[1]; final.DownloadedSize != int64(len(payload)) || final.TotalSize != int64(len(payload)) {
+		t.Fatalf("final event = %+v", final)
+	}
+}
+
+func TestDownloadCallbackCountsLastAttempt(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, strings.Repeat("busy", 100), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var last DownloadInfo
+	_, err := Get[string](New(RetryCount(1).RetryFixedInterval(time.Millisecond).RetryCondition(func(resp *req.Response, _ error) bool {
+		return resp != nil && resp.StatusCode == http.StatusServiceUnavailable
+	})), srv.URL, DownloadCallback(func(info DownloadInfo) {
+		last = info
+	}))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if last.DownloadedSize != 2 || last.TotalSize != 2 {
+		t.Fatalf("last event = %+v", last)
+	}
+}
+
+func TestDownloadCallbackNil(t *testing.T) {
+	if len(DownloadCallback(nil).ops) != 0 {
+		t.Fatalf("expected nil callback to be ignored")
+	}
+}
+
+func TestDownloadProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 4096))
+	}))
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = w
+
+	_, err = Get[[]byte](New(), srv.URL, DownloadProgress())
+	_ = w.Close()
+	os.Stdout = stdout
+	_, _ = io.Copy(buf, r)
+	_ = r.Close()
+
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "download 100.0% 4.0 KiB/4.0 KiB") || !strings.HasSuffix(out, " done\n") {
+		t.Fatalf("unexpected progress output %q", out)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	cases := []struct {
+		in                string
+		first, last, size int64
+		ok                bool
+	}{
+		{"bytes 0-99/1000", 0, 99, 1000, true},
+		{"bytes 100-199/*", 100, 199, 0, true},
+		{"bytes */1000", -1, -1, 1000, true},
+		{"bytes 5-1/10", 0, 0, 0, false},
+		{"items 0-1/2", 0, 0, 0, false},
+	}
+	for _, tc := range cases {
+		first, last, size, ok := parseContentRange(tc.in)
+		if first != tc.first || last != tc.last || size != tc.size || ok != tc.ok {
+			t.Fatalf("%q = %d %d %d %v", tc.in, first, last, size, ok)
+		}
+	}
+}
+
+func assertOnlyFile(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Fatalf("%s contains %q, want %q", dir, got, names)
+	}
+}
+
+func assertFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s has %d bytes, want %d matching bytes", path, len(got), len(want))
+	}
+}
@@ -0,0 +1,114 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// handlerRemoteAddr is what handlers see as r.RemoteAddr, matching httptest.NewRequest.
+const handlerRemoteAddr = "192.0.2.1:1234"
+
+// HandlerTransport serves every request with h in-process instead of over
+// the network. Connections are in-memory pipes carrying real HTTP/1.1, so
+// streaming bodies, trailers, cancellation, retries, dumps and tracing
+// behave as they do against a remote server. Both http and https URLs are
+// served in plain text; no sockets are opened and no proxy is used.
+// @group Client Options
+//
+// Applies to client configuration only.
+// Example: call a handler without a server
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /hello", func(w http.ResponseWriter, r *http.Request) {
+//		_, _ = w.Write([]byte("hi"))
+//	})
+//	c := httpx.New(httpx.HandlerTransport(mux), httpx.BaseURL("http://app.local"))
+//	res, _ := httpx.Get[string](c, "/hello")
+//	fmt.Println(res)
+//	// hi
+func HandlerTransport(h http.Handler) OptionBuilder {
+	return OptionBuilder{}.HandlerTransport(h)
+}
+
+func (b OptionBuilder) HandlerTransport(h http.Handler) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		if h == nil {
+			return
+		}
+		dial := handlerDialer(h)
+		c.req.EnableForceHTTP1()
+		c.req.SetProxy(nil)
+		c.req.SetDial(dial)
+		c.req.SetDialTLS(dial)
+	}))
+}
+
+// handlerDialer returns a dial function that serves each new connection with
+// its own http.Server, which exits once the connection is closed.
+func handlerDialer(h http.Handler) func(context.Context, string, string) (net.Conn, error) {
+	srv := &http.Server{Handler: h}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		client, server := net.Pipe()
+		conn := &pipeConn{Conn: server, local: pipeAddr(addr), remote: pipeAddr(handlerRemoteAddr), done: make(chan struct{})}
+		go func() { _ = srv.Serve(&singleConnListener{conn: conn}) }()
+		return &pipeConn{Conn: client, local: pipeAddr(handlerRemoteAddr), remote: pipeAddr(addr)}, nil
+	}
+}
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "memory" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn gives a net.Pipe end meaningful addresses and, on the server
+// side, signals when it has been closed.
+type pipeConn struct {
+	net.Conn
+	local, remote pipeAddr
+	once          sync.Once
+	done          chan struct{}
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.local }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *pipeConn) Close() error {
+	err := c.Conn.Close()
+	if c.done != nil {
+		c.once.Do(func() { close(c.done) })
+	}
+	return err
+}
+
+// singleConnListener hands out one connection and then blocks until it is
+// closed, so http.Server.Serve returns once the connection is done.
+type singleConnListener struct {
+	mu   sync.Mutex
+	conn *pipeConn
+	used bool
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if !l.used {
+		l.used = true
+		l.mu.Unlock()
+		return l.conn, nil
+	}
+	l.mu.Unlock()
+	<-l.conn.done
+	return nil, net.ErrClosed
+}
+
+func (l *singleConnListener) Close() error {
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.local
+}
@@ -0,0 +1,115 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+func TestHandlerTransport(t *testing.T) {
+	var attempts atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /flaky", func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"remote":"` + r.RemoteAddr + `","host":"` + r.Host + `"}`))
+	})
+	mux.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		for _, part := range []string{"a", "b", "c"} {
+			_, _ = w.Write([]byte(part))
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set("X-Checksum", "abc")
+	})
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(r.Body)
+		_, _ = w.Write(buf.Bytes())
+	})
+
+	var dump bytes.Buffer
+	c := New(HandlerTransport(mux), BaseURL("https://app.internal"), RetryCount(2).RetryFixedInterval(time.Millisecond).RetryCondition(func(resp *req.Response, err error) bool {
+		return err != nil || resp.StatusCode >= 500
+	}))
+	got, err := Get[map[string]string](c, "/flaky", DumpTo(&dump))
+	if err != nil {
+		t.Fatalf("flaky: %v", err)
+	}
+	if attempts.Load() != 3 || got["remote"] != handlerRemoteAddr || got["host"] != "app.internal" {
+		t.Fatalf("attempts = %d, body = %v", attempts.Load(), got)
+	}
+	if !strings.Contains(dump.String(), "GET /flaky HTTP/1.1") || !strings.Contains(dump.String(), `"remote"`) {
+		t.Fatalf("dump = %q", dump.String())
+	}
+
+	body, resp, err := Do[string](c.Req().R().SetURL("/stream"))
+	if err != nil || body != "abc" {
+		t.Fatalf("stream = %q, %v", body, err)
+	}
+	if resp.Response.Trailer.Get("X-Checksum") != "abc" {
+		t.Fatalf("trailer = %v", resp.Response.Trailer)
+	}
+
+	echo, err := Post[string, string](c, "/echo", strings.Repeat("x", 1<<20))
+	if err != nil || len(echo) != 1<<20 {
+		t.Fatalf("echo = %d bytes, %v", len(echo), err)
+	}
+
+	_, err = Get[string](c, "/missing")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 HTTPError, got %v", err)
+	}
+}
+
+func TestHandlerTransportCancellation(t *testing.T) {
+	canceled := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(canceled)
+	})
+
+	c := New(HandlerTransport(h))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := GetCtx[string](c, ctx, "http://app.internal/slow")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler did not observe cancellation")
+	}
+}
+
+func TestHandlerTransportTracing(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	var timings []Timing
+	c := New(HandlerTransport(h), TimingHook(func(_ *req.Response, timing Timing) {
+		timings = append(timings, timing)
+	}))
+	for range 2 {
+		if _, err := Get[string](c, "http://app.internal:8080/"); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	if len(timings) != 2 || timings[0].RemoteAddr != "app.internal:8080" || !timings[1].ConnReused {
+		t.Fatalf("timings = %+v", timings)
+	}
+}
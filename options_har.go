@@ -0,0 +1,489 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/imroc/req/v3"
+)
+
+// HARRecorder collects the traffic of clients using RecordHAR as a HAR 1.2
+// document that browser devtools and HAR viewers can open. Every attempt,
+// including retries and attempts that failed without a response, becomes an
+// entry with timings, headers, cookies and bodies. Headers, query parameters
+// and JSON fields are masked as configured by RedactHeaders, RedactQuery and
+// RedactJSONFields on the recording client. A recorder is safe for concurrent
+// use and may be shared by several clients.
+// @group Debugging
+type HARRecorder struct {
+	mu         sync.Mutex
+	entries    []harEntry
+	path       string
+	maxEntries int
+	// version counts changes to entries; saveMu serializes writes of path,
+	// and saved is the version last written, so an older snapshot never
+	// replaces a newer one.
+	version int
+	saveMu  sync.Mutex
+	saved   int
+}
+
+// defaultRollingHAREntries bounds a rolling recorder created without a limit,
+// since every entry rewrites the whole file.
+const defaultRollingHAREntries = 1000
+
+// NewHARRecorder returns a recorder that keeps every entry in memory.
+// @group Debugging
+//
+// Example: record a session and save it
+//
+//	rec := httpx.NewHARRecorder()
+//	c := httpx.New(httpx.AsChrome().RecordHAR(rec))
+//	_, _ = httpx.Get[string](c, "https://httpbin.org/get")
+//	f, _ := os.Create("session.har")
+//	defer f.Close()
+//	_, _ = rec.WriteTo(f)
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+// NewRollingHARRecorder returns a recorder that keeps the most recent
+// maxEntries entries and rewrites path after every entry, so the file always
+// holds a complete document even if the process dies. The file is replaced
+// atomically, outside the lock requests record under. A maxEntries of zero or
+// less keeps the last 1000 entries.
+// @group Debugging
+//
+// Example: keep the last 500 exchanges on disk
+//
+//	rec := httpx.NewRollingHARRecorder("scrape.har", 500)
+//	c := httpx.New(httpx.RecordHAR(rec))
+//	_ = c
+func NewRollingHARRecorder(path string, maxEntries int) *HARRecorder {
+	if maxEntries <= 0 {
+		maxEntries = defaultRollingHAREntries
+	}
+	return &HARRecorder{path: path, maxEntries: maxEntries}
+}
+
+// WriteTo writes the recorded entries as a HAR 1.2 JSON document.
+// @group Debugging
+//
+// Example: write the recording to stdout
+//
+//	rec := httpx.NewHARRecorder()
+//	_, _ = rec.WriteTo(os.Stdout)
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	data, err := h.marshal()
+	h.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Len returns the number of recorded entries.
+// @group Debugging
+//
+// Example: count recorded entries
+//
+//	rec := httpx.NewHARRecorder()
+//	fmt.Println(rec.Len()) // 0
+func (h *HARRecorder) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Reset discards the recorded entries.
+// @group Debugging
+//
+// Example: start a fresh recording
+//
+//	rec := httpx.NewHARRecorder()
+//	rec.Reset()
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = nil
+}
+
+// RecordHAR records every attempt made by the client into rec. It enables
+// tracing so entries carry DNS, connect, TLS and wait timings.
+// @group Debugging
+//
+// Applies to client configuration only.
+// Example: compare a browser profile with the real browser
+//
+//	rec := httpx.NewHARRecorder()
+//	c := httpx.New(httpx.AsFirefox().RecordHAR(rec))
+//	_, _ = httpx.Get[string](c, "https://httpbin.org/headers")
+//	_, _ = rec.WriteTo(os.Stdout)
+func RecordHAR(rec *HARRecorder) OptionBuilder {
+	return OptionBuilder{}.RecordHAR(rec)
+}
+
+func (b OptionBuilder) RecordHAR(rec *HARRecorder) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		if rec == nil {
+			return
+		}
+		c.req.EnableTraceAll()
+		c.req.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+			return func(r *req.Request) (*req.Response, error) {
+				started := time.Now()
+				resp, err := rt.RoundTrip(r)
+				rec.add(newHAREntry(started, r, resp, err, boundClient(r, c).redact))
+				return resp, err
+			}
+		})
+	}))
+}
+
+func (h *HARRecorder) add(entry harEntry) {
+	h.mu.Lock()
+	h.entries = append(h.entries, entry)
+	if h.maxEntries > 0 && len(h.entries) > h.maxEntries {
+		h.entries = slices.Delete(h.entries, 0, len(h.entries)-h.maxEntries)
+	}
+	if h.path == "" {
+		h.mu.Unlock()
+		return
+	}
+	h.version++
+	version := h.version
+	data, err := h.marshal()
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+	if version <= h.saved {
+		return
+	}
+	// Recording must not fail requests; a failed save is retried on the next entry.
+	if h.save(data) == nil {
+		h.saved = version
+	}
+}
+
+func (h *HARRecorder) save(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
+
+func (h *HARRecorder) marshal() ([]byte, error) {
+	entries := h.entries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	return json.MarshalIndent(harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "httpx", Version: "v2"},
+		Entries: entries,
+	}}, "", "  ")
+}
+
+// The har* types follow the HAR 1.2 specification.
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+func newHAREntry(started time.Time, r *req.Request, resp *req.Response, err error, redact *redaction) harEntry {
+	header := r.Headers
+	proto := "HTTP/1.1"
+	if r.RawRequest != nil {
+		header = r.RawRequest.Header
+		if r.RawRequest.Proto != "" {
+			proto = r.RawRequest.Proto
+		}
+	}
+	redactedURL := redact.url(r.URL)
+	entry := harEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      r.Method,
+			URL:         redactedURL,
+			HTTPVersion: proto,
+			Cookies:     harRequestCookies(header, redact),
+			Headers:     harHeaders(header, redact),
+			QueryString: harQuery(redactedURL),
+			HeadersSize: -1,
+			BodySize:    int64(len(r.Body)),
+		},
+		Response: harResponse{Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1},
+	}
+	if len(r.Body) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: header.Get("Content-Type"),
+			Text:     string(redact.body(r.Body)),
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	timing := TimingOf(timingResponse(r, resp))
+	entry.Time = harMillis(timing.Total)
+	entry.Timings = harTimingsOf(timing)
+	if host, _, err := net.SplitHostPort(timing.RemoteAddr); err == nil {
+		entry.ServerIPAddress = host
+	}
+
+	if resp == nil || resp.Response == nil {
+		return entry
+	}
+	body := resp.Bytes()
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     harResponseCookies(resp.Cookies(), redact),
+		Headers:     harHeaders(resp.Header, redact),
+		Content:     harContentOf(body, resp.Header.Get("Content-Type"), redact),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+	return entry
+}
+
+// harHeaders lists h in the order the request was sent when a header order was
+// set, as browser profiles do, and alphabetically otherwise.
+func harHeaders(h http.Header, redact *redaction) []harNameValue {
+	order := h[req.HeaderOderKey]
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		if key != req.HeaderOderKey && key != req.PseudoHeaderOderKey {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		ia := slices.IndexFunc(order, func(o string) bool { return strings.EqualFold(o, a) })
+		ib := slices.IndexFunc(order, func(o string) bool { return strings.EqualFold(o, b) })
+		switch {
+		case ia >= 0 && ib >= 0:
+			return ia - ib
+		case ia >= 0:
+			return -1
+		case ib >= 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	masked := redact.header(h)
+	out := []harNameValue{}
+	for _, key := range keys {
+		for _, value := range masked[key] {
+			out = append(out, harNameValue{Name: key, Value: value})
+		}
+	}
+	return out
+}
+
+func harQuery(rawURL string) []harNameValue {
+	out := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return out
+	}
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		key, value, _ := strings.Cut(part, "=")
+		name, _ := url.QueryUnescape(key)
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		out = append(out, harNameValue{Name: name, Value: value})
+	}
+	return out
+}
+
+func harRequestCookies(h http.Header, redact *redaction) []harCookie {
+	out := []harCookie{}
+	masked := redact.redactsHeader("Cookie")
+	for _, cookie := range (&http.Request{Header: h}).Cookies() {
+		value := cookie.Value
+		if masked {
+			value = redactedValue
+		}
+		out = append(out, harCookie{Name: cookie.Name, Value: value})
+	}
+	return out
+}
+
+func harResponseCookies(cookies []*http.Cookie, redact *redaction) []harCookie {
+	out := []harCookie{}
+	masked := redact.redactsHeader("Set-Cookie")
+	for _, cookie := range cookies {
+		value := cookie.Value
+		if masked {
+			value = redactedValue
+		}
+		c := harCookie{
+			Name:     cookie.Name,
+			Value:    value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func harContentOf(body []byte, contentType string, redact *redaction) harContent {
+	content := harContent{Size: int64(len(body)), MimeType: contentType}
+	if len(body) == 0 {
+		return content
+	}
+	if utf8.Valid(body) {
+		content.Text = string(redact.body(body))
+		return content
+	}
+	content.Text = base64.StdEncoding.EncodeToString(body)
+	content.Encoding = "base64"
+	return content
+}
+
+// harTimingsOf maps a Timing onto HAR phases, using -1 for phases that did not
+// apply, such as connecting on a reused connection.
+func harTimingsOf(t Timing) harTimings {
+	timings := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Send: 0}
+	if !t.ConnReused {
+		if t.DNS > 0 {
+			timings.DNS = harMillis(t.DNS)
+		}
+		if t.Connect > 0 || t.TLS > 0 {
+			// HAR counts the TLS handshake as part of connect.
+			timings.Connect = harMillis(t.Connect + t.TLS)
+		}
+		if t.TLS > 0 {
+			timings.SSL = harMillis(t.TLS)
+		}
+	}
+	if t.TTFB > 0 {
+		timings.Wait = harMillis(max(t.TTFB-t.DNS-t.Connect-t.TLS, 0))
+		timings.Receive = harMillis(max(t.Total-t.TTFB, 0))
+	} else {
+		timings.Wait = harMillis(t.Total)
+	}
+	return timings
+}
+
+func harMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,190 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func decodeHAR(t *testing.T, data []byte) harDocument {
+	t.Helper()
+	var doc harDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode HAR: %v\n%s", err, data)
+	}
+	if doc.Log.Version != "1.2" || doc.Log.Creator.Name != "httpx" {
+		t.Fatalf("unexpected log header: %+v", doc.Log)
+	}
+	return doc
+}
+
+func TestRecordHAR(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret", Path: "/", HttpOnly: true})
+		if r.URL.Path == "/binary" {
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"response-secret","id":1}`))
+	}))
+	defer srv.Close()
+
+	rec := NewHARRecorder()
+	c := New(RecordHAR(rec).RedactJSONFields("token", "password").RedactQuery("key"))
+	_, err := Post[map[string]string, map[string]any](c, srv.URL+"/login?key=query-secret&page=1", map[string]string{"password": "body-secret"},
+		Header("Cookie", "a=cookie-secret; b=2"),
+	)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, err := Get[[]byte](c, srv.URL+"/binary"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, err := Get[string](c, "http://127.0.0.1:1/down"); err == nil {
+		t.Fatalf("expected connection error")
+	}
+	if rec.Len() != 3 {
+		t.Fatalf("entries = %d", rec.Len())
+	}
+
+	var buf bytes.Buffer
+	if _, err := rec.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, secret := range []string{"server-secret", "response-secret", "query-secret", "body-secret", "cookie-secret"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Fatalf("%s leaked into HAR", secret)
+		}
+	}
+	doc := decodeHAR(t, buf.Bytes())
+	login, binary, failed := doc.Log.Entries[0], doc.Log.Entries[1], doc.Log.Entries[2]
+
+	if login.Request.Method != "POST" || !strings.HasSuffix(login.Request.URL, "/login?key=REDACTED&page=1") {
+		t.Fatalf("request = %+v", login.Request)
+	}
+	if len(login.Request.QueryString) != 2 || login.Request.QueryString[1] != (harNameValue{Name: "page", Value: "1"}) {
+		t.Fatalf("query = %+v", login.Request.QueryString)
+	}
+	if login.Request.PostData == nil || !strings.Contains(login.Request.PostData.Text, `"password":"REDACTED"`) {
+		t.Fatalf("post data = %+v", login.Request.PostData)
+	}
+	if len(login.Request.Cookies) != 2 || login.Request.Cookies[0].Value != "REDACTED" {
+		t.Fatalf("request cookies = %+v", login.Request.Cookies)
+	}
+	if login.Response.Status != 201 || login.Response.StatusText != "Created" || login.Response.Content.MimeType != "application/json" {
+		t.Fatalf("response = %+v", login.Response)
+	}
+	if len(login.Response.Cookies) != 1 || !login.Response.Cookies[0].HTTPOnly || login.Response.Cookies[0].Value != "REDACTED" {
+		t.Fatalf("response cookies = %+v", login.Response.Cookies)
+	}
+	if login.ServerIPAddress != "127.0.0.1" || login.Time <= 0 || login.Timings.Connect < 0 || login.Timings.SSL != -1 {
+		t.Fatalf("entry timing = %v %+v", login.Time, login.Timings)
+	}
+	if binary.Response.Content.Encoding != "base64" || binary.Response.Content.Text != "//4A" {
+		t.Fatalf("binary content = %+v", binary.Response.Content)
+	}
+	if failed.Response.Status != 0 || failed.Error == "" {
+		t.Fatalf("failed entry = %+v", failed)
+	}
+
+	rec.Reset()
+	if rec.Len() != 0 {
+		t.Fatalf("reset kept entries")
+	}
+}
+
+func TestRollingHARRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "session.har")
+	rec := NewRollingHARRecorder(path, 2)
+	c := New(RecordHAR(rec))
+	for _, p := range []string{"/a", "/b", "/c"} {
+		if _, err := Get[string](c, srv.URL+p); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	doc := decodeHAR(t, data)
+	if len(doc.Log.Entries) != 2 || !strings.HasSuffix(doc.Log.Entries[0].Request.URL, "/b") || doc.Log.Entries[1].Response.Content.Text != "/c" {
+		t.Fatalf("rolling entries = %+v", doc.Log.Entries)
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	if len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
+
+func TestRollingHARRecorderConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "session.har")
+	rec := NewRollingHARRecorder(path, 0)
+	if rec.maxEntries != defaultRollingHAREntries {
+		t.Fatalf("maxEntries = %d, want the default cap", rec.maxEntries)
+	}
+	c := New(RecordHAR(rec))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = Get[string](c, srv.URL)
+		}()
+	}
+	wg.Wait()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if doc := decodeHAR(t, data); len(doc.Log.Entries) != 20 {
+		t.Fatalf("file has %d entries, want the latest snapshot with 20", len(doc.Log.Entries))
+	}
+}
+
+func TestHARHeadersFollowHeaderOrder(t *testing.T) {
+	h := http.Header{
+		"Accept":     {"*/*"},
+		"User-Agent": {"test"},
+		"X-B":        {"b"},
+		"X-A":        {"a"},
+	}
+	h["__header_order__"] = []string{"user-agent", "accept"}
+	got := harHeaders(h, nil)
+	var names []string
+	for _, kv := range got {
+		names = append(names, kv.Name)
+	}
+	if strings.Join(names, ",") != "User-Agent,Accept,X-A,X-B" {
+		t.Fatalf("header order = %v", names)
+	}
+}
+
+func TestHARTimingsOf(t *testing.T) {
+	fresh := harTimingsOf(Timing{DNS: 2 * time.Millisecond, Connect: 3 * time.Millisecond, TLS: 5 * time.Millisecond, TTFB: 20 * time.Millisecond, Total: 25 * time.Millisecond})
+	if fresh != (harTimings{Blocked: -1, DNS: 2, Connect: 8, SSL: 5, Wait: 10, Receive: 5}) {
+		t.Fatalf("fresh connection timings = %+v", fresh)
+	}
+	reused := harTimingsOf(Timing{TTFB: 4 * time.Millisecond, Total: 6 * time.Millisecond, ConnReused: true})
+	if reused != (harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: 4, Receive: 2}) {
+		t.Fatalf("reused connection timings = %+v", reused)
+	}
+}
@@ -1,6 +1,15 @@
 package httpx
 
-import "github.com/imroc/req/v3"
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/imroc/req/v3"
+	utls "github.com/refraction-networking/utls"
+)
 
 // TLSFingerprintKind selects a TLS fingerprint preset.
 type TLSFingerprintKind int
@@ -30,19 +39,19 @@ func (b OptionBuilder) TLSFingerprint(kind TLSFingerprintKind) OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
 		switch kind {
 		case TLSFingerprintChromeKind:
-			c.req.SetTLSFingerprintChrome()
+			c.setTLSFingerprint(utls.HelloChrome_Auto)
 		case TLSFingerprintFirefoxKind:
-			c.req.SetTLSFingerprintFirefox()
+			c.setTLSFingerprint(utls.HelloFirefox_Auto)
 		case TLSFingerprintSafariKind:
-			c.req.SetTLSFingerprintSafari()
+			c.setTLSFingerprint(utls.HelloSafari_Auto)
 		case TLSFingerprintEdgeKind:
-			c.req.SetTLSFingerprintEdge()
+			c.setTLSFingerprint(utls.HelloEdge_Auto)
 		case TLSFingerprintAndroidKind:
-			c.req.SetTLSFingerprintAndroid()
+			c.setTLSFingerprint(utls.HelloAndroid_11_OkHttp)
 		case TLSFingerprintIOSKind:
-			c.req.SetTLSFingerprintIOS()
+			c.setTLSFingerprint(utls.HelloIOS_Auto)
 		case TLSFingerprintRandomizedKind:
-			c.req.SetTLSFingerprintRandomized()
+			c.setTLSFingerprint(utls.HelloRandomized)
 		}
 	}))
 }
@@ -60,7 +69,7 @@ func TLSFingerprintChrome() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintChrome() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintChrome()
+		c.setTLSFingerprint(utls.HelloChrome_Auto)
 	}))
 }
 
@@ -77,7 +86,7 @@ func TLSFingerprintFirefox() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintFirefox() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintFirefox()
+		c.setTLSFingerprint(utls.HelloFirefox_Auto)
 	}))
 }
 
@@ -94,7 +103,7 @@ func TLSFingerprintSafari() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintSafari() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintSafari()
+		c.setTLSFingerprint(utls.HelloSafari_Auto)
 	}))
 }
 
@@ -111,7 +120,7 @@ func TLSFingerprintEdge() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintEdge() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintEdge()
+		c.setTLSFingerprint(utls.HelloEdge_Auto)
 	}))
 }
 
@@ -128,7 +137,7 @@ func TLSFingerprintAndroid() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintAndroid() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintAndroid()
+		c.setTLSFingerprint(utls.HelloAndroid_11_OkHttp)
 	}))
 }
 
@@ -145,7 +154,7 @@ func TLSFingerprintIOS() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintIOS() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintIOS()
+		c.setTLSFingerprint(utls.HelloIOS_Auto)
 	}))
 }
 
@@ -162,10 +171,144 @@ func TLSFingerprintRandomized() OptionBuilder {
 
 func (b OptionBuilder) TLSFingerprintRandomized() OptionBuilder {
 	return b.add(clientOnly(func(c *Client) {
-		c.req.SetTLSFingerprintRandomized()
+		c.setTLSFingerprint(utls.HelloRandomized)
 	}))
 }
 
+// setTLSFingerprint performs TLS handshakes with utls using the given preset.
+// Unlike req's SetTLSFingerprint, the handshake reads the transport's TLS config
+// on every connection and carries over client certificates, ServerName and the
+// verification callbacks, so the TLS options compose with fingerprints.
+func (c *Client) setTLSFingerprint(id utls.ClientHelloID) {
+	c.tlsHello = &id
+	c.installTLSHandshake()
+}
+
+// installTLSHandshake replaces req's TLS handshake when a fingerprint or pins are
+// configured. It must be re-run on clones, since it is bound to c's transport.
+func (c *Client) installTLSHandshake() {
+	if c.tlsHello == nil && c.pins == nil {
+		return
+	}
+	c.req.SetTLSHandshake(tlsHandshake(c.req.Transport, c.tlsHello, c.pins))
+}
+
+func tlsHandshake(t *req.Transport, hello *utls.ClientHelloID, pins *pinSet) func(ctx context.Context, addr string, plainConn net.Conn) (net.Conn, *tls.ConnectionState, error) {
+	return func(ctx context.Context, addr string, plainConn net.Conn) (net.Conn, *tls.ConnectionState, error) {
+		cfg := &tls.Config{}
+		if t.TLSClientConfig != nil {
+			cfg = t.TLSClientConfig.Clone()
+		}
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+		if pins != nil {
+			pins.install(cfg, host)
+		}
+		if hello == nil {
+			conn := tls.Client(plainConn, cfg)
+			if err := conn.HandshakeContext(ctx); err != nil {
+				return nil, nil, err
+			}
+			state := conn.ConnectionState()
+			return conn, &state, nil
+		}
+		conn := utls.UClient(plainConn, utlsConfig(cfg), *hello)
+		if err := conn.HandshakeContext(ctx); err != nil {
+			return nil, nil, err
+		}
+		// Presets pin their own version and cipher lists, so enforce the
+		// configured limits on what was actually negotiated.
+		state := tlsConnectionState(conn.ConnectionState())
+		if err := checkNegotiatedTLS(cfg, state); err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+		return &utlsConn{conn}, &state, nil
+	}
+}
+
+func utlsConfig(cfg *tls.Config) *utls.Config {
+	out := &utls.Config{
+		ServerName:                  cfg.ServerName,
+		Rand:                        cfg.Rand,
+		Time:                        cfg.Time,
+		RootCAs:                     cfg.RootCAs,
+		NextProtos:                  cfg.NextProtos,
+		InsecureSkipVerify:          cfg.InsecureSkipVerify,
+		CipherSuites:                cfg.CipherSuites,
+		SessionTicketsDisabled:      cfg.SessionTicketsDisabled,
+		MinVersion:                  cfg.MinVersion,
+		MaxVersion:                  cfg.MaxVersion,
+		DynamicRecordSizingDisabled: cfg.DynamicRecordSizingDisabled,
+		KeyLogWriter:                cfg.KeyLogWriter,
+		VerifyPeerCertificate:       cfg.VerifyPeerCertificate,
+	}
+	for _, cert := range cfg.Certificates {
+		uc := utls.Certificate{
+			Certificate:                 cert.Certificate,
+			PrivateKey:                  cert.PrivateKey,
+			OCSPStaple:                  cert.OCSPStaple,
+			SignedCertificateTimestamps: cert.SignedCertificateTimestamps,
+			Leaf:                        cert.Leaf,
+		}
+		for _, scheme := range cert.SupportedSignatureAlgorithms {
+			uc.SupportedSignatureAlgorithms = append(uc.SupportedSignatureAlgorithms, utls.SignatureScheme(scheme))
+		}
+		out.Certificates = append(out.Certificates, uc)
+	}
+	if verify := cfg.VerifyConnection; verify != nil {
+		out.VerifyConnection = func(cs utls.ConnectionState) error {
+			return verify(tlsConnectionState(cs))
+		}
+	}
+	return out
+}
+
+func checkNegotiatedTLS(cfg *tls.Config, state tls.ConnectionState) error {
+	if cfg.MinVersion != 0 && state.Version < cfg.MinVersion {
+		return fmt.Errorf("httpx: negotiated %s is below the minimum %s", tls.VersionName(state.Version), tls.VersionName(cfg.MinVersion))
+	}
+	if cfg.MaxVersion != 0 && state.Version > cfg.MaxVersion {
+		return fmt.Errorf("httpx: negotiated %s is above the maximum %s", tls.VersionName(state.Version), tls.VersionName(cfg.MaxVersion))
+	}
+	// TLS 1.3 suites are not configurable, matching crypto/tls.
+	if len(cfg.CipherSuites) > 0 && state.Version < tls.VersionTLS13 && !slices.Contains(cfg.CipherSuites, state.CipherSuite) {
+		return fmt.Errorf("httpx: negotiated cipher suite %s is not allowed", tls.CipherSuiteName(state.CipherSuite))
+	}
+	return nil
+}
+
+// utlsConn exposes a crypto/tls connection state, which the HTTP/2 transport expects.
+type utlsConn struct {
+	*utls.UConn
+}
+
+func (c *utlsConn) ConnectionState() tls.ConnectionState {
+	return tlsConnectionState(c.UConn.ConnectionState())
+}
+
+func tlsConnectionState(cs utls.ConnectionState) tls.ConnectionState {
+	return tls.ConnectionState{
+		Version:                     cs.Version,
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 cs.CipherSuite,
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual:  cs.NegotiatedProtocolIsMutual,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            cs.PeerCertificates,
+		VerifiedChains:              cs.VerifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OCSPResponse:                cs.OCSPResponse,
+		TLSUnique:                   cs.TLSUnique,
+	}
+}
+
 func headerOrder(keys ...string) OptionBuilder {
 	return OptionBuilder{}.add(bothOption(
 		func(c *Client) {
@@ -206,5 +349,6 @@ func multipartBoundary(fn func() string) OptionBuilder {
 	}
 	return OptionBuilder{}.add(clientOnly(func(c *Client) {
 		c.req.SetMultipartBoundaryFunc(fn)
+		c.multipartBoundary = fn
 	}))
 }
@@ -0,0 +1,214 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Logger emits one structured record per attempt, including retries, with the
+// method, URL, status, duration, bytes sent and received, attempt number and,
+// for failures, the error and an error class (timeout, canceled, dns, tls,
+// transport, client_error or server_error). Failed attempts are logged at
+// slog.LevelWarn or above. When the logger is enabled for debug the record
+// also carries request and response headers, and bodies if LogBodies is set.
+// Header values and query parameters are masked as configured by
+// RedactHeaders and RedactQuery. A nil logger uses slog.Default().
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: log every attempt
+//
+//	c := httpx.New(httpx.Logger(slog.Default(), slog.LevelInfo))
+//	res, _ := httpx.Get[string](c, "https://httpbin.org/get")
+//	httpx.Dump(res) // dumps string
+func Logger(logger *slog.Logger, level slog.Level) OptionBuilder {
+	return OptionBuilder{}.Logger(logger, level)
+}
+
+func (b OptionBuilder) Logger(logger *slog.Logger, level slog.Level) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		installed := c.logging != nil && c.logging.logger != nil
+		cfg := c.logging.clone()
+		cfg.logger = logger
+		cfg.level = level
+		c.logging = cfg
+		if !installed {
+			c.req.WrapRoundTripFunc(c.logAttempt)
+		}
+	}))
+}
+
+// LogBodies adds request and response bodies, truncated to maxBytes, to debug
+// records written by Logger, with RedactJSONFields applied. Bodies streamed
+// from an io.Reader are not logged.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: include the first kilobyte of bodies at debug level
+//
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	c := httpx.New(httpx.Logger(logger, slog.LevelDebug).LogBodies(1024))
+//	_ = c
+func LogBodies(maxBytes int) OptionBuilder {
+	return OptionBuilder{}.LogBodies(maxBytes)
+}
+
+func (b OptionBuilder) LogBodies(maxBytes int) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.logging.clone()
+		cfg.bodyLimit = maxBytes
+		c.logging = cfg
+	}))
+}
+
+// logConfig is shared by clients cloned from each other, so it is copied
+// rather than modified when options change it.
+type logConfig struct {
+	logger    *slog.Logger
+	level     slog.Level
+	bodyLimit int
+}
+
+func (l *logConfig) clone() *logConfig {
+	if l == nil {
+		return &logConfig{}
+	}
+	cp := *l
+	return &cp
+}
+
+func (c *Client) logAttempt(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		bound := boundClient(r, c)
+		if cfg := bound.logging; cfg != nil && cfg.logger != nil {
+			cfg.log(r, resp, err, time.Since(start), bound.redact)
+		}
+		return resp, err
+	}
+}
+
+func (l *logConfig) log(r *req.Request, resp *req.Response, err error, elapsed time.Duration, redact *redaction) {
+	ctx := r.Context()
+	class := errorClass(resp, err)
+	level := l.level
+	if class != "" && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("url", redact.url(r.URL)),
+		slog.Int("attempt", r.RetryAttempt+1),
+		slog.Duration("duration", elapsed),
+		slog.Int64("bytes_sent", requestBodySize(r)),
+	}
+	if resp != nil && resp.Response != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.Int64("bytes_received", max(responseBodySize(resp), 0)),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if class != "" {
+		attrs = append(attrs, slog.String("error_class", class))
+	}
+	if l.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, l.debugAttrs(r, resp, redact)...)
+	}
+	l.logger.LogAttrs(ctx, level, "httpx request", attrs...)
+}
+
+func (l *logConfig) debugAttrs(r *req.Request, resp *req.Response, redact *redaction) []slog.Attr {
+	sent := r.Headers
+	if r.RawRequest != nil {
+		sent = r.RawRequest.Header
+	}
+	attrs := []slog.Attr{headerGroup("request_headers", redact.header(sent))}
+	if resp != nil && resp.Response != nil {
+		attrs = append(attrs, headerGroup("response_headers", redact.header(resp.Header)))
+	}
+	if l.bodyLimit > 0 {
+		if len(r.Body) > 0 {
+			attrs = append(attrs, slog.String("request_body", truncateBody(redact.body(r.Body), l.bodyLimit)))
+		}
+		if resp != nil && len(resp.Bytes()) > 0 {
+			attrs = append(attrs, slog.String("response_body", truncateBody(redact.body(resp.Bytes()), l.bodyLimit)))
+		}
+	}
+	return attrs
+}
+
+func headerGroup(name string, h http.Header) slog.Attr {
+	attrs := make([]any, 0, len(h))
+	for key, values := range h {
+		if len(values) == 1 {
+			attrs = append(attrs, slog.String(key, values[0]))
+		} else {
+			attrs = append(attrs, slog.Any(key, values))
+		}
+	}
+	return slog.Group(name, attrs...)
+}
+
+func truncateBody(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", body[:limit], len(body)-limit)
+}
+
+// errorClass groups failures into a small, stable set of values suitable for
+// log queries and alerting. It returns "" for successful attempts.
+func errorClass(resp *req.Response, err error) string {
+	if err == nil {
+		switch {
+		case resp == nil || resp.Response == nil:
+			return ""
+		case resp.StatusCode >= 500:
+			return "server_error"
+		case resp.StatusCode >= 400:
+			return "client_error"
+		}
+		return ""
+	}
+	var (
+		netErr     net.Error
+		dnsErr     *net.DNSError
+		certErr    *tls.CertificateVerificationError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		pinErr     *PinningError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &certErr), errors.As(err, &unknownErr), errors.As(err, &hostErr),
+		errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &pinErr):
+		return "tls"
+	}
+	return "transport"
+}
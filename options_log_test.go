@@ -0,0 +1,158 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggerRecordPerAttempt(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	c := New(Logger(logger, slog.LevelInfo).RedactQuery("token"))
+	_, err := Post[string, string](c, srv.URL+"/items?token=secret&page=2", "payload",
+		RetryCount(1).
+			RetryFixedInterval(time.Millisecond).
+			RetryCondition(func(resp *req.Response, _ error) bool {
+				return resp != nil && resp.StatusCode == http.StatusServiceUnavailable
+			}),
+	)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	records := decodeLogRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want one per attempt", len(records))
+	}
+	first, second := records[0], records[1]
+	if first["level"] != "WARN" || first["error_class"] != "server_error" || first["status"] != 503.0 || first["attempt"] != 1.0 {
+		t.Fatalf("unexpected failed attempt record: %v", first)
+	}
+	if second["level"] != "INFO" || second["status"] != 200.0 || second["attempt"] != 2.0 || second["bytes_received"] != 5.0 || second["bytes_sent"] != 7.0 {
+		t.Fatalf("unexpected success record: %v", second)
+	}
+	if _, ok := second["error_class"]; ok {
+		t.Fatalf("success record has an error class: %v", second)
+	}
+	if url := second["url"].(string); strings.Contains(url, "secret") || !strings.HasSuffix(url, "/items?token=REDACTED&page=2") {
+		t.Fatalf("url not redacted: %s", url)
+	}
+	if _, ok := second["request_headers"]; ok {
+		t.Fatalf("headers logged above debug level")
+	}
+}
+
+func TestLoggerDebugHeadersAndBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := New(Logger(logger, slog.LevelDebug).RedactHeaders("x-session-token"))
+	_, err := Post[string, string](c, srv.URL, "request body",
+		Header("Authorization", "Bearer secret").
+			Header("X-Session-Token", "token").
+			Header("X-Trace", "1").
+			LogBodies(10),
+	)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	records := decodeLogRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("records = %d", len(records))
+	}
+	record := records[0]
+	sent := record["request_headers"].(map[string]any)
+	if sent["Authorization"] != "REDACTED" || sent["X-Session-Token"] != "REDACTED" || sent["X-Trace"] != "1" {
+		t.Fatalf("request headers = %v", sent)
+	}
+	received := record["response_headers"].(map[string]any)
+	if received["Set-Cookie"] != "REDACTED" {
+		t.Fatalf("response headers = %v", received)
+	}
+	if record["request_body"] != "request bo... (2 bytes truncated)" {
+		t.Fatalf("request body = %v", record["request_body"])
+	}
+	if record["response_body"] != "xxxxxxxxxx... (90 bytes truncated)" {
+		t.Fatalf("response body = %v", record["response_body"])
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("secret leaked into logs: %s", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := Get[string](c, srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if record := decodeLogRecords(t, &buf)[0]; record["response_body"] != nil {
+		t.Fatalf("per-request LogBodies leaked into the base client")
+	}
+}
+
+func TestLoggerTransportError(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(Logger(slog.New(slog.NewJSONHandler(&buf, nil)), slog.LevelInfo))
+	if _, err := Get[string](c, "http://127.0.0.1:1/"); err == nil {
+		t.Fatalf("expected connection error")
+	}
+	records := decodeLogRecords(t, &buf)
+	if len(records) != 1 || records[0]["error_class"] != "transport" || records[0]["error"] == nil {
+		t.Fatalf("records = %v", records)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	cases := map[string]error{
+		"canceled":  context.Canceled,
+		"timeout":   &net.OpError{Op: "dial", Err: context.DeadlineExceeded},
+		"dns":       &net.DNSError{Err: "no such host", Name: "example.invalid"},
+		"tls":       &PinningError{Host: "example.com"},
+		"transport": errors.New("connection reset"),
+	}
+	for want, err := range cases {
+		if got := errorClass(nil, err); got != want {
+			t.Fatalf("errorClass(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
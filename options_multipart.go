@@ -0,0 +1,423 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// Part is one part of a body built with Multipart, MultipartRelated or
+// MultipartMixed.
+// @group Upload Options
+type Part struct {
+	name     string
+	filename string
+	header   textproto.MIMEHeader
+	size     int64
+	open     func() (io.ReadCloser, error)
+}
+
+// FieldPart returns a form field part.
+// @group Upload Options
+//
+// Example: a form field
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+//		httpx.Multipart(httpx.FieldPart("title", "Quarterly report")),
+//	)
+func FieldPart(name, value string) Part {
+	return bytesPart(name, "", []byte(value))
+}
+
+// BytesPart returns a file part holding content.
+// @group Upload Options
+//
+// Example: a file from memory
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+//		httpx.Multipart(httpx.BytesPart("file", "report.txt", []byte("hello"))),
+//	)
+func BytesPart(name, filename string, content []byte) Part {
+	return bytesPart(name, filename, content)
+}
+
+// FilePart returns a file part read from path while the request is sent. The
+// file is opened again for each attempt, so parts survive retries.
+// @group Upload Options
+//
+// Example: a file from disk
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+//		httpx.Multipart(httpx.FilePart("video", "/tmp/video.mp4").WithContentType("video/mp4")),
+//	)
+func FilePart(name, path string) Part {
+	size := int64(-1)
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		size = info.Size()
+	}
+	return Part{
+		name:     name,
+		filename: filepath.Base(path),
+		size:     size,
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+}
+
+// ReaderPart returns a file part read from reader while the request is sent.
+// The size is what is left to read: it is taken from Seek, or else from Size
+// or Len, and can be set with WithSize otherwise. Only readers that implement
+// io.Seeker can be sent again on retries.
+// @group Upload Options
+//
+// Example: a streamed part
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+//		httpx.Multipart(httpx.ReaderPart("file", "report.txt", strings.NewReader("hello"))),
+//	)
+func ReaderPart(name, filename string, reader io.Reader) Part {
+	size := int64(-1)
+	start := int64(-1)
+	// Seek measures what is left of a partly read reader, which Size does not.
+	if s, ok := reader.(io.Seeker); ok {
+		if cur, err := s.Seek(0, io.SeekCurrent); err == nil {
+			start = cur
+			if end, err := s.Seek(0, io.SeekEnd); err == nil {
+				size = end - cur
+			}
+			_, _ = s.Seek(cur, io.SeekStart)
+		}
+	}
+	if size < 0 {
+		switch v := reader.(type) {
+		case interface{ Size() int64 }:
+			size = v.Size()
+		case interface{ Len() int }:
+			size = int64(v.Len())
+		}
+	}
+	opened := false
+	return Part{
+		name:     name,
+		filename: filename,
+		size:     size,
+		open: func() (io.ReadCloser, error) {
+			if opened {
+				s, ok := reader.(io.Seeker)
+				if !ok || start < 0 {
+					return nil, fmt.Errorf("httpx: multipart part %q cannot be sent again", name)
+				}
+				if _, err := s.Seek(start, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			opened = true
+			return io.NopCloser(reader), nil
+		},
+	}
+}
+
+// WithContentType sets the Content-Type of the part. File parts default to
+// application/octet-stream.
+// @group Upload Options
+//
+// Example: a JSON part
+//
+//	part := httpx.FieldPart("meta", `{"title":"report"}`).WithContentType("application/json")
+//	_ = part
+func (p Part) WithContentType(contentType string) Part {
+	return p.WithHeader("Content-Type", contentType)
+}
+
+// WithHeader sets a header of the part, such as Content-ID for
+// MultipartRelated.
+// @group Upload Options
+//
+// Example: reference a part by ID
+//
+//	part := httpx.FilePart("logo", "/tmp/logo.png").WithHeader("Content-ID", "<logo>")
+//	_ = part
+func (p Part) WithHeader(key, value string) Part {
+	header := make(textproto.MIMEHeader, len(p.header)+1)
+	for k, v := range p.header {
+		header[k] = v
+	}
+	header.Set(key, value)
+	p.header = header
+	return p
+}
+
+// WithSize declares the size of a part whose reader cannot report it, so
+// that the request can carry a Content-Length.
+// @group Upload Options
+//
+// Example: a pipe of known length
+//
+//	pr, pw := io.Pipe()
+//	go func() {
+//		_, _ = pw.Write([]byte("hello"))
+//		_ = pw.Close()
+//	}()
+//	part := httpx.ReaderPart("file", "report.txt", pr).WithSize(5)
+//	_ = part
+func (p Part) WithSize(size int64) Part {
+	p.size = size
+	return p
+}
+
+// Multipart sends parts as a multipart/form-data body, in the given order.
+// Parts are streamed while the request is sent, so memory use does not grow
+// with their size, and the request carries a Content-Length when the size of
+// every part is known. Multipart replaces any other request body.
+// @group Upload Options
+//
+// Applies to individual requests only.
+// Example: fields and files in one request
+//
+//	c := httpx.New()
+//	res, _ := httpx.Post[any, map[string]any](c, "https://httpbin.org/post", nil,
+//		httpx.Multipart(
+//			httpx.FieldPart("title", "Quarterly report"),
+//			httpx.FilePart("file", "/tmp/report.pdf").WithContentType("application/pdf"),
+//		),
+//	)
+//	httpx.Dump(res) // dumps map[string]any
+//	// #map[string]interface {} {
+//	//   form => #map[string]interface {} {
+//	//     title => "Quarterly report" #string
+//	//   }
+//	// }
+func Multipart(parts ...Part) OptionBuilder {
+	return OptionBuilder{}.Multipart(parts...)
+}
+
+func (b OptionBuilder) Multipart(parts ...Part) OptionBuilder {
+	return b.add(requestOnly(func(r *req.Request) {
+		setMultipart(r, "form-data", parts)
+	}))
+}
+
+// MultipartRelated sends parts as a multipart/related body (RFC 2387), in
+// the given order. The first part is the root, and its Content-Type becomes
+// the type parameter of the body. Parts stream as with Multipart.
+// @group Upload Options
+//
+// Applies to individual requests only.
+// Example: metadata and media in one request
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/upload?uploadType=multipart", nil,
+//		httpx.MultipartRelated(
+//			httpx.FieldPart("", `{"name":"video.mp4"}`).WithContentType("application/json"),
+//			httpx.FilePart("", "/tmp/video.mp4").WithContentType("video/mp4"),
+//		),
+//	)
+func MultipartRelated(parts ...Part) OptionBuilder {
+	return OptionBuilder{}.MultipartRelated(parts...)
+}
+
+func (b OptionBuilder) MultipartRelated(parts ...Part) OptionBuilder {
+	return b.add(requestOnly(func(r *req.Request) {
+		setMultipart(r, "related", parts)
+	}))
+}
+
+// MultipartMixed sends parts as a multipart/mixed body, in the given order.
+// Parts with a file name are marked as attachments. Parts stream as with
+// Multipart.
+// @group Upload Options
+//
+// Applies to individual requests only.
+// Example: a batch of documents
+//
+//	c := httpx.New()
+//	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/batch", nil,
+//		httpx.MultipartMixed(
+//			httpx.FieldPart("", "first").WithContentType("text/plain"),
+//			httpx.BytesPart("", "second.txt", []byte("second")),
+//		),
+//	)
+func MultipartMixed(parts ...Part) OptionBuilder {
+	return OptionBuilder{}.MultipartMixed(parts...)
+}
+
+func (b OptionBuilder) MultipartMixed(parts ...Part) OptionBuilder {
+	return b.add(requestOnly(func(r *req.Request) {
+		setMultipart(r, "mixed", parts)
+	}))
+}
+
+func bytesPart(name, filename string, content []byte) Part {
+	return Part{
+		name:     name,
+		filename: filename,
+		size:     int64(len(content)),
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}
+}
+
+// multipartBody streams parts for each attempt of a request.
+type multipartBody struct {
+	subtype  string
+	boundary string
+	parts    []Part
+	headers  []textproto.MIMEHeader
+	size     int64
+}
+
+func setMultipart(r *req.Request, subtype string, parts []Part) {
+	c := boundClient(r, nil)
+	body := &multipartBody{
+		subtype:  subtype,
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		parts:    parts,
+	}
+	if c != nil && c.multipartBoundary != nil {
+		body.boundary = c.multipartBoundary()
+	}
+	for _, p := range parts {
+		body.headers = append(body.headers, p.partHeader(subtype))
+	}
+	body.size = body.length()
+
+	params := map[string]string{"boundary": body.boundary}
+	if subtype == "related" && len(parts) > 0 {
+		if root, _, err := mime.ParseMediaType(body.headers[0].Get("Content-Type")); err == nil {
+			params["type"] = root
+		}
+	}
+	r.SetHeader("Content-Type", mime.FormatMediaType("multipart/"+subtype, params))
+	r.Body = nil
+	r.GetBody = body.open
+	if c != nil {
+		c.sizeMultipartBodies()
+	}
+}
+
+// partHeader returns the MIME header of p in a body of subtype.
+func (p Part) partHeader(subtype string) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader, len(p.header)+2)
+	switch {
+	case subtype == "form-data":
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(p.name))
+		if p.filename != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(p.filename))
+		}
+		header.Set("Content-Disposition", disposition)
+	case p.filename != "":
+		header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, escapeQuotes(p.filename)))
+	}
+	if p.filename != "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	for k, v := range p.header {
+		header[k] = v
+	}
+	return header
+}
+
+// length returns the exact size of the encoded body, or -1 when a part has
+// an unknown size.
+func (m *multipartBody) length() int64 {
+	var n countingWriter
+	w := multipart.NewWriter(&n)
+	_ = w.SetBoundary(m.boundary)
+	for i, p := range m.parts {
+		if p.size < 0 {
+			return -1
+		}
+		_, _ = w.CreatePart(m.headers[i])
+		n += countingWriter(p.size)
+	}
+	_ = w.Close()
+	return int64(n)
+}
+
+func (m *multipartBody) open() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(m.write(pw))
+	}()
+	return &multipartReader{PipeReader: pr, size: m.size}, nil
+}
+
+func (m *multipartBody) write(dst io.Writer) error {
+	w := multipart.NewWriter(dst)
+	if err := w.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	for i, p := range m.parts {
+		part, err := w.CreatePart(m.headers[i])
+		if err != nil {
+			return err
+		}
+		rc, err := p.open()
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(part, rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if p.size >= 0 && n != p.size {
+			return fmt.Errorf("httpx: multipart part %q has %d bytes, expected %d", p.name, n, p.size)
+		}
+	}
+	return w.Close()
+}
+
+// multipartReader is the body of one attempt. The transport reads its size
+// to send a Content-Length.
+type multipartReader struct {
+	*io.PipeReader
+	size int64
+}
+
+// sizeMultipartBodies makes requests with a Multipart body of known size
+// carry a Content-Length instead of being chunked. It is installed on the
+// per-request clone of the client.
+func (c *Client) sizeMultipartBodies() {
+	if c.multipartSized {
+		return
+	}
+	c.multipartSized = true
+	c.req.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			if body, ok := r.Body.(*multipartReader); ok && body.size >= 0 {
+				r = r.Clone(r.Context())
+				r.ContentLength = body.size
+			}
+			return rt.RoundTrip(r)
+		}
+	})
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
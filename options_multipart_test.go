@@ -0,0 +1,247 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imroc/req/v3"
+)
+
+type receivedPart struct {
+	header http.Header
+	body   string
+}
+
+type multipartServer struct {
+	*httptest.Server
+	contentType   string
+	contentLength int64
+	chunked       bool
+	parts         []receivedPart
+	requests      int
+}
+
+func newMultipartServer(t *testing.T, status int) *multipartServer {
+	t.Helper()
+	s := &multipartServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests++
+		s.contentType = r.Header.Get("Content-Type")
+		s.contentLength = r.ContentLength
+		s.chunked = len(r.TransferEncoding) > 0
+		s.parts = nil
+		_, params, err := mime.ParseMediaType(s.contentType)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(p)
+			s.parts = append(s.parts, receivedPart{header: http.Header(p.Header), body: string(body)})
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestMultipart(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(
+		FieldPart("title", "Quarterly report"),
+		FilePart("file", path).WithContentType("application/pdf").WithHeader("X-Part", "1"),
+		ReaderPart("notes", `a"b.txt`, strings.NewReader("notes")),
+	))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !strings.HasPrefix(srv.contentType, "multipart/form-data; boundary=") {
+		t.Fatalf("unexpected content type %q", srv.contentType)
+	}
+	if srv.chunked || srv.contentLength <= 0 {
+		t.Fatalf("expected a Content-Length, got %d (chunked %v)", srv.contentLength, srv.chunked)
+	}
+	if len(srv.parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(srv.parts))
+	}
+	want := []struct{ disposition, contentType, body string }{
+		{`form-data; name="title"`, "", "Quarterly report"},
+		{`form-data; name="file"; filename="report.pdf"`, "application/pdf", "%PDF"},
+		{`form-data; name="notes"; filename="a\"b.txt"`, "application/octet-stream", "notes"},
+	}
+	for i, w := range want {
+		p := srv.parts[i]
+		if p.header.Get("Content-Disposition") != w.disposition || p.header.Get("Content-Type") != w.contentType || p.body != w.body {
+			t.Fatalf("part %d: unexpected %v %q", i, p.header, p.body)
+		}
+	}
+	if srv.parts[1].header.Get("X-Part") != "1" {
+		t.Fatalf("expected part header, got %v", srv.parts[1].header)
+	}
+}
+
+func TestMultipartUnknownSizeIsChunked(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("streamed"))
+		_ = pw.Close()
+	}()
+
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(ReaderPart("file", "a.txt", pr)))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !srv.chunked {
+		t.Fatalf("expected chunked body, got Content-Length %d", srv.contentLength)
+	}
+	if len(srv.parts) != 1 || srv.parts[0].body != "streamed" {
+		t.Fatalf("unexpected parts %+v", srv.parts)
+	}
+}
+
+func TestMultipartWithSize(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("streamed"))
+		_ = pw.Close()
+	}()
+
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(ReaderPart("file", "a.txt", pr).WithSize(8)))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if srv.chunked || srv.contentLength <= 8 {
+		t.Fatalf("expected a Content-Length, got %d", srv.contentLength)
+	}
+}
+
+func TestMultipartSizeMismatch(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(ReaderPart("file", "a.txt", io.LimitReader(strings.NewReader("short"), 5)).WithSize(10)))
+	if err == nil || !strings.Contains(err.Error(), `multipart part "file" has 5 bytes, expected 10`) {
+		t.Fatalf("expected size mismatch error, got %v", err)
+	}
+}
+
+func TestMultipartPartlyReadReader(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	reader := strings.NewReader("header:body")
+	if _, err := io.CopyN(io.Discard, reader, int64(len("header:"))); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(ReaderPart("file", "a.txt", reader)))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(srv.parts) != 1 || srv.parts[0].body != "body" {
+		t.Fatalf("expected the unread rest, got %+v", srv.parts)
+	}
+}
+
+func TestMultipartRelated(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, MultipartRelated(
+		FieldPart("", `{"name":"a"}`).WithContentType("application/json; charset=utf-8"),
+		BytesPart("", "a.bin", []byte{1, 2}).WithHeader("Content-ID", "<media>"),
+	))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(srv.contentType)
+	if mediaType != "multipart/related" || params["type"] != "application/json" {
+		t.Fatalf("unexpected content type %q", srv.contentType)
+	}
+	if len(srv.parts) != 2 || srv.parts[0].header.Get("Content-Disposition") != "" {
+		t.Fatalf("unexpected root part %+v", srv.parts)
+	}
+	if p := srv.parts[1]; p.header.Get("Content-ID") != "<media>" || p.header.Get("Content-Disposition") != `attachment; filename="a.bin"` {
+		t.Fatalf("unexpected media part %v", p.header)
+	}
+}
+
+func TestMultipartMixed(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, MultipartMixed(
+		FieldPart("", "first").WithContentType("text/plain"),
+		FieldPart("", "second"),
+	))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !strings.HasPrefix(srv.contentType, "multipart/mixed; boundary=") {
+		t.Fatalf("unexpected content type %q", srv.contentType)
+	}
+	if len(srv.parts) != 2 || srv.parts[0].body != "first" || srv.parts[1].body != "second" {
+		t.Fatalf("unexpected parts %+v", srv.parts)
+	}
+}
+
+func TestMultipartReplaysOnRetry(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusServiceUnavailable)
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("file"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, _ = Post[any, map[string]any](New(), srv.URL, nil,
+		RetryCount(1),
+		RetryFixedInterval(0),
+		RetryCondition(func(resp *req.Response, _ error) bool {
+			return resp != nil && resp.StatusCode == http.StatusServiceUnavailable
+		}),
+		Multipart(FilePart("file", path), ReaderPart("seek", "b.txt", bytes.NewReader([]byte("seek")))),
+	)
+	if srv.requests != 2 {
+		t.Fatalf("expected a retry, got %d requests", srv.requests)
+	}
+	if len(srv.parts) != 2 || srv.parts[0].body != "file" || srv.parts[1].body != "seek" {
+		t.Fatalf("expected replayed parts, got %+v", srv.parts)
+	}
+}
+
+func TestMultipartUsesProfileBoundary(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	c := New(multipartBoundary(func() string { return "profile-boundary" }))
+	if _, err := Post[any, map[string]any](c, srv.URL, nil, Multipart(FieldPart("a", "b"))); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if srv.contentType != "multipart/form-data; boundary=profile-boundary" {
+		t.Fatalf("unexpected content type %q", srv.contentType)
+	}
+}
+
+func TestMultipartMissingFile(t *testing.T) {
+	srv := newMultipartServer(t, http.StatusOK)
+	_, err := Post[any, map[string]any](New(), srv.URL, nil, Multipart(FilePart("file", filepath.Join(t.TempDir(), "missing"))))
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
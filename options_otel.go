@@ -0,0 +1,285 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const otelScope = "github.com/goforj/httpx/v2"
+
+// OTel instruments the client with OpenTelemetry tracing and metrics.
+// Each call made through the request helpers (Get, Post, GetCtx, ...) gets a
+// client span, and every attempt, including retries, gets a child span carrying
+// http.request.resend_count. Attributes follow the HTTP semantic conventions,
+// W3C trace context and baggage are injected into outgoing headers, and the
+// http.client.request.duration, http.client.request.body.size and
+// http.client.response.body.size histograms are recorded per attempt.
+// Nil providers fall back to the global ones. Requests sent through Do or
+// Req() only get attempt spans.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: trace and meter a client
+//
+//	c := httpx.New(httpx.OTel(otel.GetTracerProvider(), otel.GetMeterProvider()))
+//	res, _ := httpx.Get[string](c, "https://httpbin.org/get")
+//	httpx.Dump(res) // dumps string
+func OTel(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) OptionBuilder {
+	return OptionBuilder{}.OTel(tracerProvider, meterProvider)
+}
+
+func (b OptionBuilder) OTel(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		inst := newOTelInstrumentation(tracerProvider, meterProvider)
+		c.otel = inst
+		c.req.WrapRoundTripFunc(inst.wrapAttempt)
+	}))
+}
+
+type otelInstrumentation struct {
+	tracer       trace.Tracer
+	propagator   propagation.TextMapPropagator
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+func newOTelInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider) *otelInstrumentation {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(otelScope)
+	inst := &otelInstrumentation{
+		tracer:     tp.Tracer(otelScope),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	var errs []error
+	var err error
+	inst.duration, err = meter.Float64Histogram("http.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP client requests."),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	)
+	errs = append(errs, err)
+	inst.requestSize, err = meter.Int64Histogram("http.client.request.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP client request bodies."),
+	)
+	errs = append(errs, err)
+	inst.responseSize, err = meter.Int64Histogram("http.client.response.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP client response bodies."),
+	)
+	errs = append(errs, err)
+	// The returned instruments are usable no-ops on error, so report and carry on.
+	if err := errors.Join(errs...); err != nil {
+		otel.Handle(err)
+	}
+	return inst
+}
+
+// startCall starts instrumentation for a logical call and returns the function
+// that finishes it.
+func (c *Client) startCall(r *req.Request, method, rawURL string) func(*req.Response, error) {
+	if c.otel == nil {
+		return func(*req.Response, error) {}
+	}
+	return c.otel.startCall(r, method, rawURL)
+}
+
+func (o *otelInstrumentation) startCall(r *req.Request, method, rawURL string) func(*req.Response, error) {
+	attrs := otelMethodAttrs(method)
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		attrs = append(attrs, otelURLAttrs(u)...)
+	}
+	ctx, span := o.tracer.Start(r.Context(), otelSpanName(method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	r.SetContext(ctx)
+	return func(resp *req.Response, err error) {
+		if r.RetryAttempt > 0 {
+			span.SetAttributes(semconv.HTTPRequestResendCount(r.RetryAttempt))
+		}
+		if r.URL != nil {
+			span.SetAttributes(otelURLAttrs(r.URL)...)
+		}
+		otelEnd(span, resp, err)
+	}
+}
+
+func (o *otelInstrumentation) wrapAttempt(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		parent := r.Context()
+		attrs := append(otelMethodAttrs(r.Method), otelURLAttrs(r.URL)...)
+		if r.RetryAttempt > 0 {
+			attrs = append(attrs, semconv.HTTPRequestResendCount(r.RetryAttempt))
+		}
+		ctx, span := o.tracer.Start(parent, otelSpanName(r.Method),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		o.propagator.Inject(ctx, propagation.HeaderCarrier(r.Headers))
+
+		// Every attempt is parented to the call, not to the previous attempt.
+		r.SetContext(ctx)
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		elapsed := time.Since(start)
+		r.SetContext(parent)
+
+		metricAttrs := append(otelMethodAttrs(r.Method), otelServerAttrs(r.URL)...)
+		if resp != nil && resp.Response != nil {
+			metricAttrs = append(metricAttrs, semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if v := otelProtocolVersion(resp.Proto); v != "" {
+				metricAttrs = append(metricAttrs, semconv.NetworkProtocolVersion(v))
+				span.SetAttributes(semconv.NetworkProtocolVersion(v))
+			}
+		}
+		if errType := otelErrorType(resp, err); errType != "" {
+			metricAttrs = append(metricAttrs, semconv.ErrorTypeKey.String(errType))
+		}
+		set := metric.WithAttributeSet(attribute.NewSet(metricAttrs...))
+		o.duration.Record(ctx, elapsed.Seconds(), set)
+		o.requestSize.Record(ctx, requestBodySize(r), set)
+		if size := responseBodySize(resp); size >= 0 {
+			o.responseSize.Record(ctx, size, set)
+		}
+		otelEnd(span, resp, err)
+		return resp, err
+	}
+}
+
+func otelEnd(span trace.Span, resp *req.Response, err error) {
+	if resp != nil && resp.Response != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
+	if err == nil && resp != nil {
+		err = resp.Err
+	}
+	if errType := otelErrorType(resp, err); errType != "" {
+		span.SetAttributes(semconv.ErrorTypeKey.String(errType))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+	span.End()
+}
+
+// otelErrorType follows the semantic conventions: the error's type for failed
+// requests, otherwise the status code for 4xx and 5xx responses.
+func otelErrorType(resp *req.Response, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%T", err)
+	}
+	if resp != nil && resp.Response != nil && resp.StatusCode >= 400 {
+		return strconv.Itoa(resp.StatusCode)
+	}
+	return ""
+}
+
+func otelSpanName(method string) string {
+	if otelKnownMethod(method) {
+		return strings.ToUpper(method)
+	}
+	return "HTTP"
+}
+
+func otelKnownMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "CONNECT", "DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT", "TRACE":
+		return true
+	}
+	return false
+}
+
+func otelMethodAttrs(method string) []attribute.KeyValue {
+	if method == "" {
+		method = "GET"
+	}
+	if otelKnownMethod(method) {
+		return []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(strings.ToUpper(method))}
+	}
+	return []attribute.KeyValue{semconv.HTTPRequestMethodOther, semconv.HTTPRequestMethodOriginal(method)}
+}
+
+func otelServerAttrs(u *url.URL) []attribute.KeyValue {
+	if u == nil {
+		return nil
+	}
+	attrs := []attribute.KeyValue{semconv.ServerAddress(u.Hostname())}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		switch u.Scheme {
+		case "https":
+			port = 443
+		case "http":
+			port = 80
+		}
+	}
+	if port > 0 {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	return attrs
+}
+
+func otelURLAttrs(u *url.URL) []attribute.KeyValue {
+	if u == nil {
+		return nil
+	}
+	full := *u
+	if full.User != nil {
+		full.User = url.UserPassword("REDACTED", "REDACTED")
+	}
+	return append(otelServerAttrs(u), semconv.URLFull(full.String()))
+}
+
+func otelProtocolVersion(proto string) string {
+	switch proto {
+	case "HTTP/1.0":
+		return "1.0"
+	case "HTTP/1.1":
+		return "1.1"
+	case "HTTP/2.0", "HTTP/2":
+		return "2"
+	case "HTTP/3.0", "HTTP/3":
+		return "3"
+	}
+	return ""
+}
+
+func requestBodySize(r *req.Request) int64 {
+	if r.RawRequest != nil && r.RawRequest.ContentLength > 0 {
+		return r.RawRequest.ContentLength
+	}
+	return int64(len(r.Body))
+}
+
+func responseBodySize(resp *req.Response) int64 {
+	if resp == nil || resp.Response == nil {
+		return -1
+	}
+	if body := resp.Bytes(); body != nil {
+		return int64(len(body))
+	}
+	return resp.ContentLength
+}
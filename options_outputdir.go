@@ -0,0 +1,468 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/imroc/req/v3"
+)
+
+// maxFileName is the longest name, in bytes, OutputDir gives a file. Most
+// file systems refuse longer names.
+const maxFileName = 255
+
+// defaultFileName names files when neither the response nor the URL does.
+const defaultFileName = "download"
+
+// CollisionPolicy decides what OutputDir does when the chosen file name is
+// already taken.
+// @group Download Options
+type CollisionPolicy int
+
+const (
+	// CollisionSuffix saves the file as name-1.ext, name-2.ext and so on.
+	// It is the default.
+	CollisionSuffix CollisionPolicy = iota
+	// CollisionOverwrite replaces the existing file.
+	CollisionOverwrite
+	// CollisionFail fails the request with an error wrapping fs.ErrExist.
+	CollisionFail
+)
+
+// OutputDir streams the response body into a file in dir and names it after
+// the response: the Content-Disposition filename (RFC 6266, preferring the
+// UTF-8 filename* form), else the last segment of the final URL path, else
+// "download". A name without an extension gets one from the Content-Type.
+// Names are reduced to a single safe path segment, so a server cannot write
+// outside dir. The body is written to a temporary file and renamed into place
+// once the request succeeds; what happens when the name is taken is set by
+// OutputCollision. OutputPathOf returns the path that was chosen. A
+// client-level OutputDir does not apply to requests that use OutputFile, nor
+// to Download and TusUpload.
+// @group Download Options
+//
+// Applies to both client defaults and individual requests.
+// Example: save reports under the names the server gives them
+//
+//	// Apply to all requests
+//	c := httpx.New(httpx.OutputDir("/tmp/reports"))
+//	_, resp, _ := httpx.Do[[]byte](c.Req().R().SetURL("https://example.com/reports/latest"))
+//	fmt.Println(httpx.OutputPathOf(resp))
+//	// /tmp/reports/q3-2025.pdf
+//
+//	// Apply to a single request
+//	_, _ = httpx.Get[[]byte](httpx.New(), "https://example.com/reports/latest", httpx.OutputDir("/tmp/reports"))
+func OutputDir(dir string) OptionBuilder {
+	return OptionBuilder{}.OutputDir(dir)
+}
+
+func (b OptionBuilder) OutputDir(dir string) OptionBuilder {
+	return b.add(bothOption(
+		func(c *Client) {
+			cfg := c.download.clone()
+			cfg.outputDir = dir
+			c.download = cfg
+			c.saveToOutputDir()
+		},
+		func(r *req.Request) {
+			var cfg *downloadConfig
+			if c := boundClient(r, nil); c != nil {
+				cfg = c.download
+			}
+			outputDir(r, dir, cfg)
+		},
+	))
+}
+
+// OutputCollision sets what OutputDir does when a file with the chosen name
+// already exists. The default is CollisionSuffix.
+// @group Download Options
+//
+// Applies to both client defaults and individual requests.
+// Example: never replace a file
+//
+//	// Apply to all requests
+//	c := httpx.New(httpx.OutputCollision(httpx.CollisionFail))
+//
+//	// Apply to a single request
+//	_, err := httpx.Get[[]byte](c, "https://example.com/reports/latest",
+//		httpx.OutputDir("/tmp/reports"),
+//		httpx.OutputCollision(httpx.CollisionOverwrite),
+//	)
+//	_ = err
+func OutputCollision(policy CollisionPolicy) OptionBuilder {
+	return OptionBuilder{}.OutputCollision(policy)
+}
+
+func (b OptionBuilder) OutputCollision(policy CollisionPolicy) OptionBuilder {
+	setPolicy := func(c *Client) {
+		cfg := c.download.clone()
+		cfg.collision = policy
+		c.download = cfg
+	}
+	return b.add(bothOption(
+		setPolicy,
+		func(r *req.Request) {
+			if c := boundClient(r, nil); c != nil {
+				setPolicy(c)
+			}
+		},
+	))
+}
+
+// OutputPathOf returns the path OutputDir saved the body of resp to, or ""
+// when the request did not use OutputDir or did not succeed.
+// @group Download Options
+//
+// Example: find the saved file
+//
+//	c := httpx.New(httpx.OutputDir("/tmp"))
+//	_, resp, err := httpx.Do[[]byte](c.Req().R().SetURL("https://example.com/export"))
+//	if err == nil {
+//		fmt.Println(httpx.OutputPathOf(resp))
+//	}
+func OutputPathOf(resp *req.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	if out, ok := resp.Request.Context().Value(outputDirKey{}).(*savedOutput); ok {
+		return out.path
+	}
+	return ""
+}
+
+type outputDirKey struct{}
+
+// savedOutput records where OutputDir put the body.
+type savedOutput struct {
+	path string
+}
+
+// saveToOutputDir applies a client-level OutputDir to requests as they are
+// sent. It runs before every attempt, so it skips requests already set up.
+func (c *Client) saveToOutputDir() {
+	if c.download != nil && c.download.outputDirHooked {
+		return
+	}
+	cfg := c.download.clone()
+	cfg.outputDirHooked = true
+	c.download = cfg
+	c.req.OnBeforeRequest(func(_ *req.Client, r *req.Request) error {
+		if _, ok := r.Context().Value(outputDirKey{}).(*savedOutput); ok {
+			return nil
+		}
+		cfg := boundClient(r, c).download
+		if cfg == nil || cfg.outputDir == "" {
+			return nil
+		}
+		outputDir(r, cfg.outputDir, cfg)
+		return nil
+	})
+}
+
+// ownBodies keeps a client-level OutputDir away from requests whose bodies
+// the caller reads itself. c must be a clone private to the caller.
+func (c *Client) ownBodies() {
+	if c.download != nil && c.download.outputDir != "" {
+		cfg := c.download.clone()
+		cfg.outputDir = ""
+		c.download = cfg
+	}
+}
+
+// outputDir saves the response to a temporary file in dir and, after a
+// successful attempt, renames it to the name the response asks for. The
+// collision policy is read when the response arrives, so options applied
+// after OutputDir count.
+func outputDir(r *req.Request, dir string, cfg *downloadConfig) {
+	out := &savedOutput{}
+	r.SetContext(context.WithValue(r.Context(), outputDirKey{}, out))
+	tmp := filepath.Join(dir, "."+rand.Text()+".tmp")
+	r.SetOutputFile(tmp)
+	r.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		if resp.Err != nil || resp.Response == nil || !resp.IsSuccessState() {
+			_ = os.Remove(tmp)
+			return nil
+		}
+		if c := boundClient(r, nil); c != nil {
+			cfg = c.download
+		}
+		name := outputFileName(resp.Response)
+		err := cfg.verify(tmp, filepath.Join(dir, name), digestHeaders(resp.Response, resp.StatusCode == http.StatusOK))
+		if err == nil {
+			out.path, err = placeFile(tmp, dir, name, cfg.collisionPolicy())
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+		return err
+	})
+}
+
+func (d *downloadConfig) collisionPolicy() CollisionPolicy {
+	if d == nil {
+		return CollisionSuffix
+	}
+	return d.collision
+}
+
+// placeFile moves tmp to dir/name according to policy and returns the path
+// it ended up at.
+func placeFile(tmp, dir, name string, policy CollisionPolicy) (string, error) {
+	dest := filepath.Join(dir, name)
+	if policy == CollisionOverwrite {
+		if err := commitFile(tmp, dest); err != nil {
+			return "", err
+		}
+		return dest, nil
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		err := commitNewFile(tmp, dest)
+		if err == nil {
+			return dest, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		if policy == CollisionFail {
+			return "", fmt.Errorf("httpx: output file %s: %w", dest, fs.ErrExist)
+		}
+		suffix := "-" + strconv.Itoa(i)
+		dest = filepath.Join(dir, truncateName(stem, maxFileName-len(suffix)-len(ext))+suffix+ext)
+	}
+}
+
+// commitNewFile moves tmp to dest unless dest exists. A hard link claims the
+// name atomically; file systems without links fall back to a check before
+// the rename.
+func commitNewFile(tmp, dest string) error {
+	err := os.Link(tmp, dest)
+	if err == nil {
+		_ = os.Remove(tmp)
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	if _, statErr := os.Lstat(dest); statErr == nil {
+		return fs.ErrExist
+	}
+	return commitFile(tmp, dest)
+}
+
+// outputFileName picks the name of the file OutputDir saves resp to.
+func outputFileName(resp *http.Response) string {
+	name := sanitizeFileName(dispositionFileName(resp.Header.Get("Content-Disposition")))
+	if name == "" && resp.Request != nil && resp.Request.URL != nil {
+		name = sanitizeFileName(pathBase(resp.Request.URL.Path))
+	}
+	if name == "" {
+		name = defaultFileName
+	}
+	if filepath.Ext(name) == "" {
+		if ext := contentTypeExtension(resp.Header.Get("Content-Type")); ext != "" {
+			name = truncateName(name, maxFileName-len(ext)) + ext
+		}
+	}
+	return name
+}
+
+// dispositionFileName returns the file name of a Content-Disposition header,
+// preferring filename* over filename as RFC 6266 asks.
+func dispositionFileName(v string) string {
+	if v == "" {
+		return ""
+	}
+	// The mime package drops filename* in ISO-8859-1, which RFC 6266 asks
+	// for, and rejects unquoted names with spaces, which servers send.
+	var plain, extended string
+	for _, param := range splitHeaderParams(v)[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "filename":
+			plain = unquoteHeaderValue(strings.TrimSpace(value))
+		case "filename*":
+			if s, ok := decodeExtValue(strings.TrimSpace(value)); ok {
+				extended = s
+			}
+		}
+	}
+	if extended != "" {
+		return extended
+	}
+	// It does handle names split into RFC 2231 continuations.
+	if _, params, err := mime.ParseMediaType(v); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	return plain
+}
+
+// splitHeaderParams splits v at semicolons outside quoted strings.
+func splitHeaderParams(v string) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(v); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && v[i] == '\\':
+			escaped = true
+		case v[i] == '"':
+			quoted = !quoted
+		case v[i] == ';' && !quoted:
+			parts = append(parts, v[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, v[start:])
+}
+
+func unquoteHeaderValue(v string) string {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return v
+	}
+	var b strings.Builder
+	for i := 1; i < len(v)-1; i++ {
+		if v[i] == '\\' && i+1 < len(v)-1 {
+			i++
+		}
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
+
+// decodeExtValue decodes an RFC 8187 value such as UTF-8”%E2%82%AC.txt.
+func decodeExtValue(v string) (string, bool) {
+	charset, rest, ok := strings.Cut(v, "'")
+	if !ok {
+		return "", false
+	}
+	_, encoded, ok := strings.Cut(rest, "'")
+	if !ok {
+		return "", false
+	}
+	raw, err := url.PathUnescape(encoded)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(charset) {
+	case "utf-8":
+		return raw, utf8.ValidString(raw)
+	case "iso-8859-1":
+		runes := make([]rune, len(raw))
+		for i := 0; i < len(raw); i++ {
+			runes[i] = rune(raw[i])
+		}
+		return string(runes), true
+	}
+	return "", false
+}
+
+// pathBase returns the last segment of a URL path.
+func pathBase(p string) string {
+	p = strings.TrimRight(p, "/")
+	return p[strings.LastIndex(p, "/")+1:]
+}
+
+// sanitizeFileName reduces name to a single path segment that is safe to
+// create on common file systems, or "" if nothing usable is left.
+func sanitizeFileName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	// Windows ignores trailing dots and spaces, which would let a name
+	// escape the collision check.
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+	if name == "" || strings.Trim(name, ".") == "" {
+		return ""
+	}
+	if isReservedFileName(name) {
+		name = "_" + name
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > maxFileName/2 {
+		ext = ""
+	}
+	if len(name) > maxFileName {
+		name = truncateName(strings.TrimSuffix(name, ext), maxFileName-len(ext)) + ext
+	}
+	return name
+}
+
+// isReservedFileName reports device names Windows reserves in every
+// directory, with or without an extension.
+func isReservedFileName(name string) bool {
+	stem, _, _ := strings.Cut(name, ".")
+	switch strings.ToUpper(strings.TrimSpace(stem)) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		return true
+	}
+	return false
+}
+
+// truncateName cuts s to at most n bytes without splitting a rune.
+func truncateName(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// commonExtensions picks the usual extension for types the mime package
+// knows several extensions for.
+var commonExtensions = map[string]string{
+	"text/plain":       ".txt",
+	"text/html":        ".html",
+	"image/jpeg":       ".jpg",
+	"application/json": ".json",
+	"application/xml":  ".xml",
+	"text/xml":         ".xml",
+	"audio/mpeg":       ".mp3",
+	"video/mpeg":       ".mpeg",
+}
+
+// contentTypeExtension returns the extension for a Content-Type, or "".
+func contentTypeExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	if ext, ok := commonExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
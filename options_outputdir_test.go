@@ -0,0 +1,211 @@
+package httpx
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func outputDirServer(t *testing.T, header http.Header, status int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("contents of " + r.URL.Path))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestOutputDirUsesContentDisposition(t *testing.T) {
+	dir := t.TempDir()
+	srv := outputDirServer(t, http.Header{
+		"Content-Disposition": {`attachment; filename="rates.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`},
+	}, http.StatusOK)
+	c := New(OutputDir(dir))
+
+	_, resp, err := Do[[]byte](c.Req().R().SetURL(srv.URL + "/export"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	want := filepath.Join(dir, "€ rates.txt")
+	if got := OutputPathOf(resp); got != want {
+		t.Fatalf("path = %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(want)
+	if err != nil || string(data) != "contents of /export" {
+		t.Fatalf("file = %q, %v", data, err)
+	}
+	if names := readDir(t, dir); len(names) != 1 {
+		t.Fatalf("expected only the saved file, got %v", names)
+	}
+}
+
+func TestOutputDirFallsBackToURLAndContentType(t *testing.T) {
+	for _, tc := range []struct {
+		path, contentType, want string
+	}{
+		{"/files/report.pdf", "application/pdf", "report.pdf"},
+		{"/files/export", "text/csv; charset=utf-8", "export.csv"},
+		{"/files/data/", "application/json", "data.json"},
+		{"/", "text/plain", "download.txt"},
+		{"/", "application/octet-stream", "download"},
+	} {
+		dir := t.TempDir()
+		srv := outputDirServer(t, http.Header{"Content-Type": {tc.contentType}}, http.StatusOK)
+		if _, err := Get[[]byte](New(), srv.URL+tc.path, OutputDir(dir)); err != nil {
+			t.Fatalf("%s: request failed: %v", tc.path, err)
+		}
+		if names := readDir(t, dir); len(names) != 1 || names[0] != tc.want {
+			t.Fatalf("%s: saved %v, want %q", tc.path, names, tc.want)
+		}
+	}
+}
+
+func TestOutputDirCollisionPolicies(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	srv := outputDirServer(t, nil, http.StatusOK)
+	url := srv.URL + "/report.pdf"
+
+	for _, want := range []string{"report-1.pdf", "report-2.pdf"} {
+		c := New(OutputDir(dir))
+		_, resp, err := Do[[]byte](c.Req().R().SetURL(url))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if got := OutputPathOf(resp); got != filepath.Join(dir, want) {
+			t.Fatalf("path = %q, want %q", got, want)
+		}
+	}
+
+	_, err := Get[[]byte](New(OutputCollision(CollisionFail)), url, OutputDir(dir))
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected fs.ErrExist, got %v", err)
+	}
+
+	if _, err := Get[[]byte](New(), url, OutputDir(dir), OutputCollision(CollisionOverwrite)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "contents of /report.pdf" {
+		t.Fatalf("expected overwritten file, got %q", data)
+	}
+	if names := readDir(t, dir); len(names) != 3 {
+		t.Fatalf("expected no leftover files, got %v", names)
+	}
+}
+
+func TestOutputDirRejectsTraversal(t *testing.T) {
+	for _, disposition := range []string{
+		`attachment; filename="../../escape.txt"`,
+		`attachment; filename="..\\..\\escape.txt"`,
+		`attachment; filename*=UTF-8''..%2F..%2Fescape.txt`,
+	} {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "out")
+		srv := outputDirServer(t, http.Header{"Content-Disposition": {disposition}}, http.StatusOK)
+		if _, err := Get[[]byte](New(), srv.URL, OutputDir(dir)); err != nil {
+			t.Fatalf("%s: request failed: %v", disposition, err)
+		}
+		if names := readDir(t, dir); len(names) != 1 || names[0] != "escape.txt" {
+			t.Fatalf("%s: saved %v", disposition, names)
+		}
+		if names := readDir(t, parent); len(names) != 1 {
+			t.Fatalf("%s: wrote outside dir: %v", disposition, names)
+		}
+	}
+}
+
+func TestOutputDirLeavesNothingOnError(t *testing.T) {
+	dir := t.TempDir()
+	srv := outputDirServer(t, nil, http.StatusNotFound)
+	if _, err := Get[[]byte](New(), srv.URL+"/missing.txt", OutputDir(dir)); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if names := readDir(t, dir); len(names) != 0 {
+		t.Fatalf("expected no files, got %v", names)
+	}
+}
+
+func TestOutputDirClientLevelYieldsToOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	srv := outputDirServer(t, nil, http.StatusOK)
+	c := New(OutputDir(dir))
+
+	file := filepath.Join(t.TempDir(), "explicit.txt")
+	if _, err := Get[[]byte](c, srv.URL+"/a.txt", OutputFile(file)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if err := Download(t.Context(), c, srv.URL+"/b.txt", filepath.Join(t.TempDir(), "b.txt")); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if names := readDir(t, dir); len(names) != 0 {
+		t.Fatalf("expected OutputDir to be skipped, got %v", names)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "contents of /a.txt" {
+		t.Fatalf("file = %q", data)
+	}
+}
+
+func TestDispositionFileName(t *testing.T) {
+	for _, tc := range []struct{ header, want string }{
+		{`attachment; filename="a.txt"`, "a.txt"},
+		{`attachment; filename=a.txt`, "a.txt"},
+		{`attachment; filename=a b.txt`, "a b.txt"},
+		{`attachment; filename="a \"b\".txt"`, `a "b".txt`},
+		{`attachment; filename*=UTF-8''%E2%82%AC.txt; filename="e.txt"`, "€.txt"},
+		{`attachment; filename*=iso-8859-1'en'%A3%20rates.txt`, "£ rates.txt"},
+		{`attachment; filename*=iso-8859-1''%A3.txt; filename="p.txt"`, "£.txt"},
+		{`attachment; filename*=koi8-r''%C1.txt; filename="fallback.txt"`, "fallback.txt"},
+		{`inline`, ""},
+		{``, ""},
+	} {
+		if got := dispositionFileName(tc.header); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	long := strings.Repeat("a", 300) + ".txt"
+	for _, tc := range []struct{ name, want string }{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Windows\win.ini`, "win.ini"},
+		{"..", ""},
+		{"...", ""},
+		{"a\x00b\nc.txt", "abc.txt"},
+		{`what?.txt`, "what_.txt"},
+		{"trailing. ", "trailing"},
+		{"CON.txt", "_CON.txt"},
+		{".env", ".env"},
+		{long, strings.Repeat("a", 251) + ".txt"},
+	} {
+		if got := sanitizeFileName(tc.name); got != tc.want {
+			t.Fatalf("%q: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
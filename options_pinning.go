@@ -0,0 +1,199 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PinningError reports a TLS connection whose certificate chain matched none of
+// the public-key pins configured for the host.
+// @group TLS
+type PinningError struct {
+	// Host is the host that was dialed.
+	Host string
+	// Pins are the configured base64 SHA-256 SPKI hashes for the host.
+	Pins []string
+	// Chain holds the base64 SHA-256 SPKI hashes of the certificates presented
+	// by the server, leaf first, followed by any verified chain not already seen.
+	Chain []string
+}
+
+// Error implements error.
+// @group TLS
+func (e *PinningError) Error() string {
+	return fmt.Sprintf("httpx: no pinned public key matched the certificate chain for %s", e.Host)
+}
+
+// PinSPKI pins the public keys a host may present, as base64 SHA-256 hashes of
+// the certificates' SubjectPublicKeyInfo (the HPKP pin-sha256 format).
+// The handshake fails with a *PinningError unless some certificate in a
+// verified chain, leaf, intermediate or root, matches one of the pins; with
+// InsecureSkipVerify only the leaf can match. Listing a backup pin
+// alongside the current one allows keys to rotate without an outage.
+// Host may be an exact name or a "*.example.com" wildcard covering subdomains;
+// hosts without pins are not checked. Calling PinSPKI again for a host replaces
+// its pins. A pin can be computed with
+// openssl x509 -pubkey -noout -in cert.pem | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets and InsecureSkipVerify.
+// Example: pin a processor's intermediate with a backup key
+//
+//	c := httpx.New(httpx.PinSPKI("api.payments.example",
+//		"r/mIkG3eEpVdm+u/ko/cwxzOMo1bk4TyHIlByibiA5E=", // current intermediate
+//		"YLh1dUR9y6Kja30RrAn7JKnbQG/uEtLMkBgFF2Fuihg=", // backup key
+//	))
+//	_ = c
+func PinSPKI(host string, sha256Base64 ...string) OptionBuilder {
+	return OptionBuilder{}.PinSPKI(host, sha256Base64...)
+}
+
+func (b OptionBuilder) PinSPKI(host string, sha256Base64 ...string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		for _, pin := range sha256Base64 {
+			if raw, err := base64.StdEncoding.DecodeString(pin); err != nil || len(raw) != sha256.Size {
+				failRequests(c, fmt.Errorf("httpx: PinSPKI: %q is not a base64 SHA-256 hash", pin))
+				return
+			}
+		}
+		if len(sha256Base64) == 0 {
+			failRequests(c, fmt.Errorf("httpx: PinSPKI: no pins given for %s", host))
+			return
+		}
+		pins := c.pins.clone()
+		pins.hosts[strings.ToLower(host)] = append([]string(nil), sha256Base64...)
+		c.pins = pins
+		c.installTLSHandshake()
+	}))
+}
+
+// PinSPKIReportOnly switches pin checks to report-only mode: a mismatch calls
+// report with the *PinningError and the connection proceeds. Use it to roll
+// out new pins safely before enforcing them.
+// @group TLS
+//
+// Applies to client configuration only.
+// Example: report pin mismatches without failing
+//
+//	c := httpx.New(httpx.
+//		PinSPKI("api.payments.example", "r/mIkG3eEpVdm+u/ko/cwxzOMo1bk4TyHIlByibiA5E=").
+//		PinSPKIReportOnly(func(err *httpx.PinningError) {
+//			fmt.Printf("pin mismatch: %v (chain %v)\n", err, err.Chain)
+//		}),
+//	)
+//	_ = c
+func PinSPKIReportOnly(report func(*PinningError)) OptionBuilder {
+	return OptionBuilder{}.PinSPKIReportOnly(report)
+}
+
+func (b OptionBuilder) PinSPKIReportOnly(report func(*PinningError)) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		pins := c.pins.clone()
+		pins.report = report
+		c.pins = pins
+		c.installTLSHandshake()
+	}))
+}
+
+// pinSet is shared by clients cloned from each other, so it is copied rather
+// than modified when options change it.
+type pinSet struct {
+	hosts  map[string][]string
+	report func(*PinningError)
+}
+
+func (p *pinSet) clone() *pinSet {
+	if p == nil {
+		return &pinSet{hosts: map[string][]string{}}
+	}
+	return &pinSet{hosts: maps.Clone(p.hosts), report: p.report}
+}
+
+func (p *pinSet) lookup(host string) []string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if pins, ok := p.hosts[host]; ok {
+		return pins
+	}
+	for i := strings.IndexByte(host, '.'); i >= 0; i = strings.IndexByte(host, '.') {
+		host = host[i+1:]
+		if pins, ok := p.hosts["*."+host]; ok {
+			return pins
+		}
+	}
+	return nil
+}
+
+// install chains the pin check for host into cfg's VerifyConnection.
+func (p *pinSet) install(cfg *tls.Config, host string) {
+	pins := p.lookup(host)
+	if len(pins) == 0 {
+		return
+	}
+	next := cfg.VerifyConnection
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := p.verify(host, pins, cs); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(cs)
+		}
+		return nil
+	}
+}
+
+func (p *pinSet) verify(host string, pins []string, cs tls.ConnectionState) error {
+	var chain []string
+	seen := map[string]bool{}
+	add := func(cert *x509.Certificate) {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		pin := base64.StdEncoding.EncodeToString(sum[:])
+		if !seen[pin] {
+			seen[pin] = true
+			chain = append(chain, pin)
+		}
+	}
+	for _, cert := range cs.PeerCertificates {
+		add(cert)
+	}
+	for _, verified := range cs.VerifiedChains {
+		for _, cert := range verified {
+			add(cert)
+		}
+	}
+	if pinsMatch(pins, cs) {
+		return nil
+	}
+	err := &PinningError{Host: host, Pins: pins, Chain: chain}
+	if p.report != nil {
+		p.report(err)
+		return nil
+	}
+	return err
+}
+
+// pinsMatch reports whether a pin matches a certificate of a verified chain.
+// Presented certificates beyond the leaf are not trusted, since a server can
+// append any public intermediate to its chain; when verification was skipped
+// only the leaf is checked.
+func pinsMatch(pins []string, cs tls.ConnectionState) bool {
+	chains := cs.VerifiedChains
+	if len(chains) == 0 && len(cs.PeerCertificates) > 0 {
+		chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if slices.Contains(pins, base64.StdEncoding.EncodeToString(sum[:])) {
+				return true
+			}
+		}
+	}
+	return false
+}
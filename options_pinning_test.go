@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestPinSPKI(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	other := newTestCert(t, nil, "other")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, 0)
+
+	cases := []struct {
+		name string
+		opt  OptionBuilder
+		ok   bool
+	}{
+		{"leaf", PinSPKI("127.0.0.1", spkiPin(server.cert)), true},
+		{"root from verified chain", PinSPKI("127.0.0.1", spkiPin(ca.cert)), true},
+		{"backup pin", PinSPKI("127.0.0.1", spkiPin(other.cert), spkiPin(server.cert)), true},
+		{"mismatch", PinSPKI("127.0.0.1", spkiPin(other.cert)), false},
+		{"mismatch with fingerprint", PinSPKI("127.0.0.1", spkiPin(other.cert)).TLSFingerprintChrome(), false},
+		{"other host", PinSPKI("example.com", spkiPin(other.cert)), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(tc.opt.RootCAs(ca.certPEM), closeIdleOnCleanup(t))
+			res, err := Get[string](c, srv.URL)
+			if tc.ok {
+				if err != nil || res != "anonymous" {
+					t.Fatalf("request failed: %v", err)
+				}
+				return
+			}
+			var pinErr *PinningError
+			if !errors.As(err, &pinErr) {
+				t.Fatalf("expected *PinningError, got %v", err)
+			}
+			if pinErr.Host != "127.0.0.1" || len(pinErr.Chain) != 2 || pinErr.Chain[0] != spkiPin(server.cert) {
+				t.Fatalf("unexpected pinning error: %+v", pinErr)
+			}
+		})
+	}
+}
+
+func TestPinSPKIIgnoresAppendedCertificates(t *testing.T) {
+	caA := newTestCert(t, nil, "ca a")
+	intermediateA := newTestCert(t, &caA, "intermediate a")
+	caB := newTestCert(t, nil, "ca b")
+	leaf := newTestCert(t, &caB, "server", "127.0.0.1")
+	// The leaf verifies through caB; the pinned intermediate is only appended.
+	served := leaf.tlsCertificate(t)
+	served.Certificate = append(served.Certificate, intermediateA.cert.Raw)
+	srv := newMTLSServer(t, served, nil, 0)
+
+	for _, opt := range []OptionBuilder{
+		RootCAs(caA.certPEM, caB.certPEM).PinSPKI("127.0.0.1", spkiPin(intermediateA.cert)),
+		RootCAs(caA.certPEM, caB.certPEM).PinSPKI("127.0.0.1", spkiPin(intermediateA.cert)).TLSFingerprintChrome(),
+		InsecureSkipVerify().PinSPKI("127.0.0.1", spkiPin(intermediateA.cert)),
+	} {
+		_, err := Get[string](New(opt, closeIdleOnCleanup(t)), srv.URL)
+		var pinErr *PinningError
+		if !errors.As(err, &pinErr) {
+			t.Fatalf("expected *PinningError, got %v", err)
+		}
+	}
+	if _, err := Get[string](New(InsecureSkipVerify().PinSPKI("127.0.0.1", spkiPin(leaf.cert)), closeIdleOnCleanup(t)), srv.URL); err != nil {
+		t.Fatalf("leaf pin without verification: %v", err)
+	}
+}
+
+func TestPinSPKIReportOnly(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	other := newTestCert(t, nil, "other")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, 0)
+
+	var reports []*PinningError
+	c := New(RootCAs(ca.certPEM).PinSPKI("127.0.0.1", spkiPin(other.cert)), closeIdleOnCleanup(t))
+	_, err := Get[string](c, srv.URL, PinSPKIReportOnly(func(err *PinningError) {
+		reports = append(reports, err)
+	}), closeIdleOnCleanup(t))
+	if err != nil {
+		t.Fatalf("report-only request failed: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Host != "127.0.0.1" {
+		t.Fatalf("reports = %v", reports)
+	}
+
+	var pinErr *PinningError
+	if _, err := Get[string](c, srv.URL); !errors.As(err, &pinErr) {
+		t.Fatalf("per-request report-only leaked into base client: %v", err)
+	}
+}
+
+func TestPinSPKIPerRequestClone(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	other := newTestCert(t, nil, "other")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, 0)
+
+	c := New(RootCAs(ca.certPEM).PinSPKI("127.0.0.1", spkiPin(server.cert)), closeIdleOnCleanup(t))
+	var pinErr *PinningError
+	if _, err := Get[string](c, srv.URL, PinSPKI("127.0.0.1", spkiPin(other.cert)), closeIdleOnCleanup(t)); !errors.As(err, &pinErr) {
+		t.Fatalf("expected per-request pins to apply, got %v", err)
+	}
+	if _, err := Get[string](c, srv.URL); err != nil {
+		t.Fatalf("base client pins changed: %v", err)
+	}
+}
+
+func TestPinSetLookup(t *testing.T) {
+	pins := (*pinSet)(nil).clone()
+	pins.hosts["api.example.com"] = []string{"a"}
+	pins.hosts["*.example.com"] = []string{"b"}
+	cases := map[string]string{
+		"api.example.com":      "a",
+		"API.example.com.":     "a",
+		"www.example.com":      "b",
+		"deep.www.example.com": "b",
+		"example.com":          "",
+		"example.org":          "",
+	}
+	for host, want := range cases {
+		if got := strings.Join(pins.lookup(host), ""); got != want {
+			t.Fatalf("lookup(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestPinSPKIInvalidPin(t *testing.T) {
+	for _, pins := range [][]string{{"not-base64!"}, {base64.StdEncoding.EncodeToString([]byte("short"))}, nil} {
+		_, err := Get[string](New(PinSPKI("example.com", pins...)), "https://127.0.0.1:1")
+		if err == nil || !strings.Contains(err.Error(), "PinSPKI") {
+			t.Fatalf("expected invalid pin error for %v, got %v", pins, err)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"golang.org/x/term"
+)
+
+const (
+	defaultBarWidth    = 80
+	defaultLogInterval = time.Second
+)
+
+var progressIDs atomic.Int64
+
+// ProgressEvent is one progress update of an upload or download.
+// @group Progress
+type ProgressEvent struct {
+	// ID identifies the transfer among concurrent ones.
+	ID int64
+	// Label names the transfer, such as "download report.pdf".
+	Label string
+	// Transferred counts the bytes sent or received so far.
+	Transferred int64
+	// Total is the size of the transfer, or 0 when unknown.
+	Total int64
+	// Rate is the average transfer rate in bytes per second.
+	Rate float64
+	// ETA estimates the time left, or 0 when the total size is unknown.
+	ETA time.Duration
+	// Done is set on the last event of a transfer. A failed transfer ends
+	// with a Done event holding the bytes moved before the failure.
+	Done bool
+}
+
+// ProgressRenderer displays progress events. Events of concurrent transfers
+// arrive interleaved and from several goroutines, so implementations must be
+// safe for concurrent use.
+// @group Progress
+type ProgressRenderer interface {
+	Render(ProgressEvent)
+}
+
+// ProgressTo sends the output of UploadProgress and DownloadProgress to r
+// instead of stdout.
+// @group Progress
+//
+// Applies to client configuration only.
+// Example: render progress on stderr
+//
+//	c := httpx.New(httpx.ProgressTo(httpx.NewProgressRenderer(os.Stderr)))
+//	_, _ = httpx.Get[[]byte](c, "https://httpbin.org/bytes/1048576", httpx.DownloadProgress())
+func ProgressTo(r ProgressRenderer) OptionBuilder {
+	return OptionBuilder{}.ProgressTo(r)
+}
+
+func (b OptionBuilder) ProgressTo(r ProgressRenderer) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.progress = r
+	}))
+}
+
+// NewProgressRenderer returns a bar renderer when w is a terminal and a log
+// renderer printing a line per second otherwise.
+// @group Progress
+//
+// Example: pick the format for the current output
+//
+//	r := httpx.NewProgressRenderer(os.Stdout)
+//	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 512, Total: 1024})
+func NewProgressRenderer(w io.Writer) ProgressRenderer {
+	if _, ok := terminalWidth(w); ok {
+		return NewBarRenderer(w)
+	}
+	return NewLogRenderer(w, defaultLogInterval)
+}
+
+// NewBarRenderer returns a renderer that draws one progress bar per active
+// transfer and redraws them in place with ANSI escapes. Bars fill the width
+// of the terminal when w is one, and 80 columns otherwise. Once every
+// transfer is done the bars are left on screen and the next ones start below.
+// @group Progress
+//
+// Example: bars on the terminal
+//
+//	r := httpx.NewBarRenderer(os.Stdout)
+//	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 1024, Total: 1024, Done: true})
+func NewBarRenderer(w io.Writer) ProgressRenderer {
+	return &barRenderer{w: w}
+}
+
+// NewLogRenderer returns a renderer that prints plain lines, suitable for log
+// files and CI output. Each transfer prints at most one line per interval,
+// plus its first and last.
+// @group Progress
+//
+// Example: log progress every five seconds
+//
+//	r := httpx.NewLogRenderer(os.Stderr, 5*time.Second)
+//	r.Render(httpx.ProgressEvent{ID: 1, Label: "upload", Transferred: 512, Total: 1024})
+//	// upload 50.0% 512 B/1.0 KiB 0 B/s
+func NewLogRenderer(w io.Writer, interval time.Duration) ProgressRenderer {
+	return &logRenderer{w: w, interval: interval, last: map[int64]time.Time{}}
+}
+
+// NewJSONRenderer returns a renderer that writes each event as a JSON object
+// on its own line, for consumption by other programs.
+// @group Progress
+//
+// Example: machine-readable progress
+//
+//	r := httpx.NewJSONRenderer(os.Stdout)
+//	r.Render(httpx.ProgressEvent{ID: 1, Label: "download", Transferred: 512, Total: 1024})
+//	// {"id":1,"label":"download","transferred":512,"total":1024,"rate":0,"eta_seconds":0,"done":false}
+func NewJSONRenderer(w io.Writer) ProgressRenderer {
+	return &jsonRenderer{w: w}
+}
+
+type barRenderer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	bars   []ProgressEvent
+	drawn  int
+	frames int
+}
+
+func (r *barRenderer) Render(ev ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := false
+	for i := range r.bars {
+		if r.bars[i].ID == ev.ID {
+			r.bars[i] = ev
+			found = true
+			break
+		}
+	}
+	if !found {
+		r.bars = append(r.bars, ev)
+	}
+	r.frames++
+
+	width, ok := terminalWidth(r.w)
+	if !ok {
+		width = defaultBarWidth
+	}
+	var sb strings.Builder
+	if r.drawn > 1 {
+		fmt.Fprintf(&sb, "\x1b[%dA", r.drawn-1)
+	}
+	allDone := true
+	for i, bar := range r.bars {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\r\x1b[2K")
+		sb.WriteString(formatBar(bar, width, r.frames))
+		allDone = allDone && bar.Done
+	}
+	r.drawn = len(r.bars)
+	if allDone {
+		sb.WriteString("\n")
+		r.bars = nil
+		r.drawn = 0
+	}
+	_, _ = io.WriteString(r.w, sb.String())
+}
+
+// formatBar lays out one bar line to fit width columns.
+func formatBar(ev ProgressEvent, width, frame int) string {
+	stats := formatProgressStats(ev)
+	if ev.Total <= 0 {
+		spin := `|/-\`
+		mark := string(spin[frame%len(spin)])
+		if ev.Done {
+			mark = "✓"
+		}
+		return ev.Label + " " + mark + " " + stats
+	}
+	percent := min(float64(ev.Transferred)/float64(ev.Total)*100, 100)
+	// Leave a column free so the cursor never wraps to the next line.
+	barWidth := width - len(ev.Label) - len(stats) - 12
+	barWidth = max(10, min(barWidth, 60))
+	filled := int(percent / 100 * float64(barWidth))
+	bar := strings.Repeat("=", filled) + strings.Repeat("-", barWidth-filled)
+	return fmt.Sprintf("%s [%s] %5.1f%% %s", ev.Label, bar, percent, stats)
+}
+
+type logRenderer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	interval time.Duration
+	last     map[int64]time.Time
+}
+
+func (r *logRenderer) Render(ev ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	last, seen := r.last[ev.ID]
+	if !ev.Done && seen && now.Sub(last) < r.interval {
+		return
+	}
+	if ev.Done {
+		delete(r.last, ev.ID)
+	} else {
+		r.last[ev.ID] = now
+	}
+	line := ev.Label + " "
+	if ev.Total > 0 {
+		line += fmt.Sprintf("%.1f%% ", min(float64(ev.Transferred)/float64(ev.Total)*100, 100))
+	}
+	line += formatProgressStats(ev)
+	if ev.Done {
+		line += " done"
+	}
+	_, _ = io.WriteString(r.w, line+"\n")
+}
+
+type jsonRenderer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type jsonProgressEvent struct {
+	ID          int64   `json:"id"`
+	Label       string  `json:"label"`
+	Transferred int64   `json:"transferred"`
+	Total       int64   `json:"total"`
+	Rate        float64 `json:"rate"`
+	ETASeconds  float64 `json:"eta_seconds"`
+	Done        bool    `json:"done"`
+}
+
+func (r *jsonRenderer) Render(ev ProgressEvent) {
+	data, err := json.Marshal(jsonProgressEvent{
+		ID:          ev.ID,
+		Label:       ev.Label,
+		Transferred: ev.Transferred,
+		Total:       ev.Total,
+		Rate:        ev.Rate,
+		ETASeconds:  ev.ETA.Seconds(),
+		Done:        ev.Done,
+	})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(data, '\n'))
+}
+
+// formatProgressStats renders the sizes, rate and ETA of an event.
+func formatProgressStats(ev ProgressEvent) string {
+	var sb strings.Builder
+	sb.WriteString(formatBytes(ev.Transferred))
+	if ev.Total > 0 {
+		sb.WriteString("/" + formatBytes(ev.Total))
+	}
+	sb.WriteString(" " + formatBytes(int64(ev.Rate)) + "/s")
+	if ev.ETA > 0 && !ev.Done {
+		sb.WriteString(" ETA " + ev.ETA.Round(time.Second).String())
+	}
+	return sb.String()
+}
+
+// terminalWidth reports the width of w when it is a terminal.
+func terminalWidth(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return defaultBarWidth, true
+	}
+	return width, true
+}
+
+var (
+	stdoutMu       sync.Mutex
+	stdoutFile     *os.File
+	stdoutRenderer ProgressRenderer
+)
+
+// progressRendererFor returns the renderer set with ProgressTo on the client
+// bound to r, or one shared by every transfer writing to stdout.
+func progressRendererFor(r *req.Request) ProgressRenderer {
+	if c := boundClient(r, nil); c != nil && c.progress != nil {
+		return c.progress
+	}
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	if stdoutRenderer == nil || stdoutFile != os.Stdout {
+		stdoutFile = os.Stdout
+		stdoutRenderer = NewProgressRenderer(os.Stdout)
+	}
+	return stdoutRenderer
+}
+
+// progressTracker renders the events of one request and remembers the last
+// of them.
+type progressTracker struct {
+	mu       sync.Mutex
+	renderer ProgressRenderer
+	last     ProgressEvent
+	seen     bool
+}
+
+func (t *progressTracker) render(ev ProgressEvent) {
+	t.mu.Lock()
+	t.last, t.seen = ev, true
+	t.mu.Unlock()
+	t.renderer.Render(ev)
+}
+
+// endOnError renders a Done event with the bytes moved so far when an
+// attempt of r fails, so that renderers drop the transfer.
+func (t *progressTracker) endOnError(r *req.Request) {
+	r.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		if resp.Err == nil {
+			return nil
+		}
+		t.mu.Lock()
+		ev, open := t.last, t.seen && !t.last.Done
+		t.mu.Unlock()
+		if open {
+			ev.Done, ev.ETA = true, 0
+			t.render(ev)
+		}
+		return nil
+	})
+}
+
+// progressID returns the ID of the transfer whose progress is stored in r's
+// context under key, so that every request of a Download or TusUpload
+// renders as one transfer, and a new ID for a standalone request.
+func progressID(r *req.Request, key any) int64 {
+	if p, ok := r.Context().Value(key).(interface{ progressID() int64 }); ok {
+		return p.progressID()
+	}
+	return progressIDs.Add(1)
+}
+
+// progressLabel names a transfer after the last segment of the request path.
+func progressLabel(direction string, r *req.Request) string {
+	raw := r.RawURL
+	if r.URL != nil {
+		raw = r.URL.String()
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return direction
+	}
+	if name := path.Base(u.Path); name != "." && name != "/" {
+		return direction + " " + name
+	}
+	return direction
+}
+
+// transferRate returns the average rate of n bytes moved since started, and
+// the time left to reach total at that rate.
+func transferRate(started, now time.Time, n, done, total int64) (float64, time.Duration) {
+	elapsed := now.Sub(started).Seconds()
+	if started.IsZero() || elapsed <= 0 {
+		return 0, 0
+	}
+	rate := float64(n) / elapsed
+	if total <= done || rate <= 0 {
+		return rate, 0
+	}
+	return rate, time.Duration(float64(total-done) / rate * float64(time.Second))
+}
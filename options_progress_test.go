@@ -0,0 +1,218 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBarRendererRedrawsConcurrentTransfers(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewBarRenderer(buf)
+
+	r.Render(ProgressEvent{ID: 1, Label: "a", Transferred: 10, Total: 100})
+	r.Render(ProgressEvent{ID: 2, Label: "b", Transferred: 5})
+	buf.Reset()
+	r.Render(ProgressEvent{ID: 1, Label: "a", Transferred: 50, Total: 100})
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\x1b[1A\r\x1b[2Ka [") {
+		t.Fatalf("expected cursor to move up before redraw, got %q", out)
+	}
+	if !strings.Contains(out, " 50.0% 50 B/100 B") || !strings.Contains(out, "\n\r\x1b[2Kb ") {
+		t.Fatalf("expected both bars to be redrawn, got %q", out)
+	}
+	if strings.HasSuffix(out, "\n") {
+		t.Fatalf("expected no trailing newline while transfers are active, got %q", out)
+	}
+
+	r.Render(ProgressEvent{ID: 1, Label: "a", Transferred: 100, Total: 100, Done: true})
+	buf.Reset()
+	r.Render(ProgressEvent{ID: 2, Label: "b", Transferred: 5, Total: 5, Done: true})
+	if out := buf.String(); !strings.HasSuffix(out, "\n") {
+		t.Fatalf("expected trailing newline once all transfers are done, got %q", out)
+	}
+
+	buf.Reset()
+	r.Render(ProgressEvent{ID: 3, Label: "c", Transferred: 1})
+	if out := buf.String(); strings.Contains(out, "\x1b[1A") || strings.Contains(out, "a [") {
+		t.Fatalf("expected a fresh bar below finished ones, got %q", out)
+	}
+}
+
+func TestFormatBar(t *testing.T) {
+	line := formatBar(ProgressEvent{Label: "x", Transferred: 50, Total: 100}, 80, 0)
+	if !strings.Contains(line, "[") || !strings.Contains(line, " 50.0% ") || len(line) >= 80 {
+		t.Fatalf("unexpected bar %q", line)
+	}
+	if line := formatBar(ProgressEvent{Label: "x", Transferred: 50, Total: 100}, 10, 0); !strings.Contains(line, "["+strings.Repeat("=", 5)+strings.Repeat("-", 5)+"]") {
+		t.Fatalf("expected minimum bar width, got %q", line)
+	}
+	if line := formatBar(ProgressEvent{Label: "x", Transferred: 5, Done: true}, 80, 0); !strings.HasPrefix(line, "x ✓ 5 B") {
+		t.Fatalf("unexpected spinner line %q", line)
+	}
+}
+
+func TestLogRendererThrottles(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewLogRenderer(buf, time.Hour)
+
+	r.Render(ProgressEvent{ID: 1, Label: "upload", Transferred: 1, Total: 4})
+	r.Render(ProgressEvent{ID: 1, Label: "upload", Transferred: 2, Total: 4})
+	r.Render(ProgressEvent{ID: 2, Label: "download", Transferred: 3})
+	r.Render(ProgressEvent{ID: 1, Label: "upload", Transferred: 4, Total: 4, Done: true})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if lines[0] != "upload 25.0% 1 B/4 B 0 B/s" {
+		t.Fatalf("unexpected first line %q", lines[0])
+	}
+	if lines[1] != "download 3 B 0 B/s" {
+		t.Fatalf("unexpected second line %q", lines[1])
+	}
+	if lines[2] != "upload 100.0% 4 B/4 B 0 B/s done" {
+		t.Fatalf("unexpected last line %q", lines[2])
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewJSONRenderer(buf)
+	r.Render(ProgressEvent{ID: 7, Label: "download", Transferred: 512, Total: 1024, Rate: 256, ETA: 2 * time.Second})
+	r.Render(ProgressEvent{ID: 7, Label: "download", Transferred: 1024, Total: 1024, Done: true})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var ev map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if ev["id"] != float64(7) || ev["transferred"] != float64(512) || ev["eta_seconds"] != float64(2) || ev["done"] != false {
+		t.Fatalf("unexpected event %v", ev)
+	}
+	if !strings.Contains(lines[1], `"done":true`) {
+		t.Fatalf("expected final event to be done, got %q", lines[1])
+	}
+}
+
+func TestNewProgressRendererNonTerminal(t *testing.T) {
+	if _, ok := NewProgressRenderer(&bytes.Buffer{}).(*logRenderer); !ok {
+		t.Fatalf("expected log renderer for non-terminal writers")
+	}
+}
+
+type recordingRenderer struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (r *recordingRenderer) Render(ev ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func TestProgressToRoutesTransfers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	rec := &recordingRenderer{}
+	c := New(ProgressTo(rec))
+	if _, err := Post[any, map[string]any](c, srv.URL+"/files/report.txt", nil,
+		FileBytes("file", "report.txt", []byte("payload")),
+		UploadProgress(),
+		DownloadProgress(),
+	); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	var upload, download *ProgressEvent
+	for i := range rec.events {
+		ev := &rec.events[i]
+		switch ev.Label {
+		case "upload report.txt":
+			upload = ev
+		case "download report.txt":
+			download = ev
+		default:
+			t.Fatalf("unexpected label %q", ev.Label)
+		}
+	}
+	if upload == nil || !upload.Done || upload.Transferred != upload.Total {
+		t.Fatalf("expected a finished upload event, got %+v", upload)
+	}
+	if download == nil || !download.Done || download.Transferred != 11 {
+		t.Fatalf("expected a finished download event, got %+v", download)
+	}
+	if upload.ID == download.ID {
+		t.Fatalf("expected distinct transfer IDs")
+	}
+}
+
+func TestProgressEndsFailedTransfers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			// Read slowly enough for the upload to report progress first.
+			for range 20 {
+				_, _ = io.CopyN(io.Discard, r.Body, 512<<10)
+				time.Sleep(20 * time.Millisecond)
+			}
+		} else {
+			w.Header().Set("Content-Length", "1000")
+			_, _ = w.Write(make([]byte, 400))
+			w.(http.Flusher).Flush()
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	rec := &recordingRenderer{}
+	c := New(ProgressTo(rec))
+	if _, err := Get[[]byte](c, srv.URL+"/file.bin", DownloadProgress()); err == nil {
+		t.Fatalf("expected the cut download to fail")
+	}
+	big := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(big, make([]byte, 32<<20), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := Post[any, []byte](c, srv.URL+"/big.bin", nil,
+		File("file", big),
+		UploadProgress(),
+	); err == nil {
+		t.Fatalf("expected the cut upload to fail")
+	}
+
+	buf := &bytes.Buffer{}
+	bars := NewBarRenderer(buf)
+	last := map[string]ProgressEvent{}
+	for _, ev := range rec.events {
+		last[ev.Label] = ev
+		bars.Render(ev)
+	}
+	if ev := last["download file.bin"]; !ev.Done || ev.Transferred != 400 || ev.Total != 1000 {
+		t.Fatalf("expected a partial Done download event, got %+v", ev)
+	}
+	if ev := last["upload big.bin"]; !ev.Done || ev.Transferred >= ev.Total {
+		t.Fatalf("expected a partial Done upload event, got %+v", ev)
+	}
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Fatalf("expected failed bars to be finished, got %q", buf.String())
+	}
+}
@@ -0,0 +1,123 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// defaultPropagationHeaders is the allowlist used when PropagateFrom or
+// ContextWithPropagation is called without explicit header names.
+var defaultPropagationHeaders = []string{"Traceparent", "Tracestate", "Baggage", "X-Request-ID"}
+
+const requestIDHeader = "X-Request-Id"
+
+type propagationKey struct{}
+
+// PropagateFrom forwards correlation headers from an incoming server request.
+// By default traceparent, tracestate, baggage and X-Request-ID are copied;
+// passing header names replaces that allowlist. When X-Request-ID is allowed
+// but missing from the incoming request a new ID is generated once, so every
+// request sent with the option shares it. No OpenTelemetry setup is needed;
+// when OTel is also configured its injected trace context takes precedence.
+// @group Observability
+//
+// Applies to both client defaults and request-time headers.
+// Example: forward trace headers from a handler
+//
+//	c := httpx.New()
+//	handler := func(w http.ResponseWriter, r *http.Request) {
+//		res, _ := httpx.Get[string](c, "https://httpbin.org/headers", httpx.PropagateFrom(r))
+//		_, _ = w.Write([]byte(res))
+//	}
+//	_ = handler
+func PropagateFrom(r *http.Request, headers ...string) OptionBuilder {
+	return OptionBuilder{}.PropagateFrom(r, headers...)
+}
+
+func (b OptionBuilder) PropagateFrom(r *http.Request, headers ...string) OptionBuilder {
+	values := propagationHeaders(r, headers)
+	return b.add(bothOption(
+		func(c *Client) {
+			c.req.SetCommonHeaders(values)
+		},
+		func(r *req.Request) {
+			r.SetHeaders(values)
+		},
+	))
+}
+
+// ContextWithPropagation returns a copy of ctx carrying the correlation headers
+// of r, chosen the same way as PropagateFrom. Requests sent with that context by
+// a client using PropagateFromContext forward them. It suits middleware that
+// prepares the context once for all outgoing calls of a handler.
+// @group Observability
+//
+// Example: capture headers in middleware
+//
+//	middleware := func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			next.ServeHTTP(w, r.WithContext(httpx.ContextWithPropagation(r.Context(), r)))
+//		})
+//	}
+//	_ = middleware
+func ContextWithPropagation(ctx context.Context, r *http.Request, headers ...string) context.Context {
+	return context.WithValue(ctx, propagationKey{}, propagationHeaders(r, headers))
+}
+
+// PropagateFromContext forwards the correlation headers stored by
+// ContextWithPropagation in each request's context. Headers set explicitly on
+// the request are kept; contexts without stored headers are left alone.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: forward headers stored in the request context
+//
+//	c := httpx.New(httpx.PropagateFromContext())
+//	handler := func(w http.ResponseWriter, r *http.Request) {
+//		ctx := httpx.ContextWithPropagation(r.Context(), r)
+//		res, _ := httpx.GetCtx[string](c, ctx, "https://httpbin.org/headers")
+//		_, _ = w.Write([]byte(res))
+//	}
+//	_ = handler
+func PropagateFromContext() OptionBuilder {
+	return OptionBuilder{}.PropagateFromContext()
+}
+
+func (b OptionBuilder) PropagateFromContext() OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.req.OnBeforeRequest(func(_ *req.Client, r *req.Request) error {
+			values, _ := r.Context().Value(propagationKey{}).(map[string]string)
+			for key, value := range values {
+				if r.Headers.Get(key) == "" {
+					r.SetHeader(key, value)
+				}
+			}
+			return nil
+		})
+	}))
+}
+
+func propagationHeaders(r *http.Request, allow []string) map[string]string {
+	if len(allow) == 0 {
+		allow = defaultPropagationHeaders
+	}
+	values := make(map[string]string, len(allow))
+	for _, key := range allow {
+		key = http.CanonicalHeaderKey(key)
+		if r != nil {
+			// tracestate and baggage may be split across lines; both are comma-joinable lists.
+			if value := strings.Join(r.Header.Values(key), ","); value != "" {
+				values[key] = value
+				continue
+			}
+		}
+		if key == requestIDHeader {
+			values[key] = rand.Text()
+		}
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package httpx
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestPropagateFrom(t *testing.T) {
+	capture := &requestCapture{}
+	srv := newCaptureServer(t, capture)
+	defer srv.Close()
+
+	incoming := httptest.NewRequest("GET", "/", nil)
+	incoming.Header.Set("traceparent", testTraceparent)
+	incoming.Header.Add("tracestate", "a=1")
+	incoming.Header.Add("tracestate", "b=2")
+	incoming.Header.Set("baggage", "user=42")
+	incoming.Header.Set("X-Request-ID", "req-1")
+	incoming.Header.Set("Authorization", "Bearer secret")
+
+	c := New()
+	if _, err := Get[string](c, srv.URL, PropagateFrom(incoming)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	want := map[string]string{
+		"Traceparent":  testTraceparent,
+		"Tracestate":   "a=1,b=2",
+		"Baggage":      "user=42",
+		"X-Request-Id": "req-1",
+	}
+	for key, value := range want {
+		if got := capture.headers.Get(key); got != value {
+			t.Fatalf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if capture.headers.Get("Authorization") != "" {
+		t.Fatalf("header outside the allowlist was forwarded")
+	}
+
+	if _, err := Get[string](c, srv.URL, PropagateFrom(incoming, "baggage")); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if capture.headers.Get("Baggage") != "user=42" || capture.headers.Get("Traceparent") != "" {
+		t.Fatalf("custom allowlist not applied: %v", capture.headers)
+	}
+}
+
+func TestPropagateFromGeneratesRequestID(t *testing.T) {
+	capture := &requestCapture{}
+	srv := newCaptureServer(t, capture)
+	defer srv.Close()
+
+	opt := PropagateFrom(httptest.NewRequest("GET", "/", nil))
+	c := New(opt)
+	if _, err := Get[string](c, srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	first := capture.headers.Get("X-Request-ID")
+	if len(first) != 26 {
+		t.Fatalf("generated request id = %q", first)
+	}
+	if capture.headers.Get("Traceparent") != "" {
+		t.Fatalf("absent trace headers should not be sent")
+	}
+	if _, err := Get[string](c, srv.URL, opt); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := capture.headers.Get("X-Request-ID"); got != first {
+		t.Fatalf("request id changed between requests: %q != %q", got, first)
+	}
+	if _, err := Get[string](New(), srv.URL, PropagateFrom(nil, "Baggage")); err != nil || capture.headers.Get("X-Request-ID") != "" {
+		t.Fatalf("request id generated outside the allowlist: %v", err)
+	}
+}
+
+func TestPropagateFromContext(t *testing.T) {
+	capture := &requestCapture{}
+	srv := newCaptureServer(t, capture)
+	defer srv.Close()
+
+	incoming := httptest.NewRequest("GET", "/", nil)
+	incoming.Header.Set("Traceparent", testTraceparent)
+	incoming.Header.Set("X-Request-ID", "req-1")
+	ctx := ContextWithPropagation(context.Background(), incoming)
+
+	c := New(PropagateFromContext())
+	if _, err := GetCtx[string](c, ctx, srv.URL, Header("X-Request-ID", "explicit")); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if capture.headers.Get("Traceparent") != testTraceparent {
+		t.Fatalf("traceparent = %q", capture.headers.Get("Traceparent"))
+	}
+	if capture.headers.Get("X-Request-ID") != "explicit" {
+		t.Fatalf("explicit header overwritten: %q", capture.headers.Get("X-Request-ID"))
+	}
+
+	if _, err := GetCtx[string](c, context.Background(), srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if capture.headers.Get("Traceparent") != "" || capture.headers.Get("X-Request-ID") != "" {
+		t.Fatalf("headers sent without a propagation context: %v", capture.headers)
+	}
+}
@@ -0,0 +1,217 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"golang.org/x/time/rate"
+)
+
+// LimitUploadRate caps how fast request bodies are sent, in bytes per second.
+// A client-level limit is one bucket shared by all requests of the client, so
+// concurrent uploads split the rate between them; a request-level limit
+// applies on top of it. Progress callbacks see the throttled pace, and waiting
+// for the bucket stops when the request context is done. A rate of zero or
+// less sets no limit.
+// @group Request Control
+//
+// Applies to both client defaults and individual requests.
+// Example: keep background uploads under 1 MiB/s
+//
+//	// Apply to all requests
+//	c := httpx.New(httpx.LimitUploadRate(1 << 20))
+//	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/sync", nil, httpx.File("file", "/tmp/backup.tar"))
+//
+//	// Apply to a single request
+//	_, _ = httpx.Post[any, map[string]any](c, "https://example.com/sync", nil,
+//		httpx.File("file", "/tmp/backup.tar"),
+//		httpx.LimitUploadRate(256<<10),
+//	)
+func LimitUploadRate(bytesPerSec int64) OptionBuilder {
+	return OptionBuilder{}.LimitUploadRate(bytesPerSec)
+}
+
+func (b OptionBuilder) LimitUploadRate(bytesPerSec int64) OptionBuilder {
+	return b.add(rateLimitOption(bytesPerSec, func(b *rateBuckets) **rate.Limiter { return &b.upload }))
+}
+
+// LimitDownloadRate caps how fast response bodies are read, in bytes per
+// second. A client-level limit is one bucket shared by all requests of the
+// client, including the segments of a Download; a request-level limit applies
+// on top of it. Progress callbacks see the throttled pace, and waiting for the
+// bucket stops when the request context is done. A rate of zero or less sets
+// no limit.
+// @group Request Control
+//
+// Applies to both client defaults and individual requests.
+// Example: download without saturating the link
+//
+//	// Apply to all requests
+//	c := httpx.New(httpx.LimitDownloadRate(2 << 20))
+//	_, _ = httpx.Get[[]byte](c, "https://example.com/large.bin")
+//
+//	// Apply to a single request
+//	_, _ = httpx.Get[[]byte](c, "https://example.com/large.bin", httpx.LimitDownloadRate(512<<10))
+func LimitDownloadRate(bytesPerSec int64) OptionBuilder {
+	return OptionBuilder{}.LimitDownloadRate(bytesPerSec)
+}
+
+func (b OptionBuilder) LimitDownloadRate(bytesPerSec int64) OptionBuilder {
+	return b.add(rateLimitOption(bytesPerSec, func(b *rateBuckets) **rate.Limiter { return &b.download }))
+}
+
+// rateLimitOption sets the limiter picked by field. A client-level limiter is
+// created once per client so that its clones share the bucket. Request
+// options are applied to the per-call clone, where the limiter goes on top
+// of the client bucket and is shared by every request of the call, such as
+// the segments of a Download.
+func rateLimitOption(bytesPerSec int64, field func(*rateBuckets) **rate.Limiter) Option {
+	return clientOnly(func(c *Client) {
+		cfg := c.rateLimits.clone()
+		buckets := &cfg.client
+		if c.perCall {
+			buckets = &cfg.call
+		}
+		*field(buckets) = newRateLimiter(bytesPerSec)
+		c.rateLimits = cfg
+		c.throttleBodies()
+	})
+}
+
+// rateBuckets holds an upload and a download bucket. A nil limiter is
+// unlimited.
+type rateBuckets struct {
+	upload   *rate.Limiter
+	download *rate.Limiter
+}
+
+// rateLimits holds the buckets of a client and those set by the request
+// options of one call.
+type rateLimits struct {
+	client rateBuckets
+	call   rateBuckets
+	// installed is set once the transport of the client throttles bodies.
+	installed bool
+}
+
+func (l *rateLimits) clone() *rateLimits {
+	if l == nil {
+		return &rateLimits{}
+	}
+	cp := *l
+	return &cp
+}
+
+// newRateLimiter returns a bucket refilled at bytesPerSec that holds a tenth
+// of a second of data, or nil for no limit.
+func newRateLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), int(max(bytesPerSec/10, 1)))
+}
+
+// throttleBodies wraps request and response bodies in readers that wait for
+// the client and call buckets.
+func (c *Client) throttleBodies() {
+	if c.rateLimits != nil && c.rateLimits.installed {
+		return
+	}
+	cfg := c.rateLimits.clone()
+	cfg.installed = true
+	c.rateLimits = cfg
+	c.req.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			limits := c.rateLimits
+			if bound, ok := r.Context().Value(clientKey{}).(*Client); ok {
+				limits = bound.rateLimits
+			}
+			upload := limits.limiters(func(b rateBuckets) *rate.Limiter { return b.upload })
+			download := limits.limiters(func(b rateBuckets) *rate.Limiter { return b.download })
+			ctx := r.Context()
+			if len(upload) > 0 && r.Body != nil && r.Body != http.NoBody {
+				r = r.Clone(ctx)
+				r.Body = &throttledReader{ReadCloser: r.Body, ctx: ctx, limiters: upload}
+				if getBody := r.GetBody; getBody != nil {
+					r.GetBody = func() (io.ReadCloser, error) {
+						body, err := getBody()
+						if err != nil {
+							return nil, err
+						}
+						return &throttledReader{ReadCloser: body, ctx: ctx, limiters: upload}, nil
+					}
+				}
+			}
+			resp, err := rt.RoundTrip(r)
+			if len(download) > 0 && resp != nil && resp.Body != nil && resp.Body != http.NoBody {
+				resp.Body = &throttledReader{ReadCloser: resp.Body, ctx: ctx, limiters: download}
+			}
+			return resp, err
+		}
+	})
+}
+
+// limiters returns the client and call limiters picked by field that are set.
+func (l *rateLimits) limiters(field func(rateBuckets) *rate.Limiter) []*rate.Limiter {
+	if l == nil {
+		return nil
+	}
+	var limiters []*rate.Limiter
+	for _, b := range []rateBuckets{l.client, l.call} {
+		if field(b) != nil {
+			limiters = append(limiters, field(b))
+		}
+	}
+	return limiters
+}
+
+// throttledReader reads at most one bucket of data at a time and waits for
+// every limiter before handing it on.
+type throttledReader struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	for _, l := range r.limiters {
+		if len(p) > l.Burst() {
+			p = p[:l.Burst()]
+		}
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		for _, l := range r.limiters {
+			if werr := waitRate(r.ctx, l, n); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
+
+// waitRate takes n tokens from l, waiting until they are available or ctx is
+// done. Unlike Limiter.WaitN it does not fail early when the wait would run
+// past the deadline of ctx.
+func waitRate(ctx context.Context, l *rate.Limiter, n int) error {
+	res := l.ReserveN(time.Now(), n)
+	delay := res.Delay()
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		res.Cancel()
+		return ctx.Err()
+	}
+}
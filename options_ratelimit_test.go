@@ -0,0 +1,153 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+func rateLimitServer(t *testing.T, payload []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLimitDownloadRate(t *testing.T) {
+	payload := randomBytes(64 << 10)
+	srv := rateLimitServer(t, payload)
+
+	var last DownloadInfo
+	start := time.Now()
+	res, err := Get[[]byte](New(), srv.URL,
+		LimitDownloadRate(128<<10),
+		DownloadCallback(func(info DownloadInfo) { last = info }),
+	)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !bytes.Equal(res, payload) {
+		t.Fatalf("body has %d bytes", len(res))
+	}
+	if elapsed < 350*time.Millisecond {
+		t.Fatalf("download took %v, expected it to be throttled", elapsed)
+	}
+	if last.DownloadedSize != int64(len(payload)) || last.Rate <= 0 || last.Rate > 192<<10 {
+		t.Fatalf("last event = %+v", last)
+	}
+}
+
+func TestLimitUploadRate(t *testing.T) {
+	srv := rateLimitServer(t, nil)
+	payload := randomBytes(64 << 10)
+
+	var last req.UploadInfo
+	start := time.Now()
+	_, err := Post[any, string](New(LimitUploadRate(128<<10)), srv.URL, nil,
+		FileBytes("file", "sync.bin", payload),
+		UploadCallback(func(info req.UploadInfo) { last = info }),
+	)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if elapsed < 350*time.Millisecond {
+		t.Fatalf("upload took %v, expected it to be throttled", elapsed)
+	}
+	if last.FileSize == 0 || last.UploadedSize != last.FileSize {
+		t.Fatalf("final callback size = %d/%d", last.UploadedSize, last.FileSize)
+	}
+}
+
+func TestLimitDownloadRateSharesClientBucket(t *testing.T) {
+	srv := rateLimitServer(t, randomBytes(32<<10))
+	c := New(LimitDownloadRate(128 << 10))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = Get[[]byte](c, srv.URL)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	// Each request alone would finish in about 150ms.
+	if elapsed < 350*time.Millisecond {
+		t.Fatalf("downloads took %v, expected them to share the bucket", elapsed)
+	}
+}
+
+func TestLimitDownloadRateRequestKeepsClientBucket(t *testing.T) {
+	srv := rateLimitServer(t, randomBytes(32<<10))
+	c := New(LimitDownloadRate(64 << 10))
+
+	start := time.Now()
+	if _, err := Get[[]byte](c, srv.URL, LimitDownloadRate(10<<20)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	// The client bucket alone takes about 450ms for the body.
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Fatalf("download took %v, expected the client limit to still apply", elapsed)
+	}
+
+	start = time.Now()
+	if _, err := Get[[]byte](New(), srv.URL, LimitDownloadRate(10<<20)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("download took %v under a request limit only", elapsed)
+	}
+}
+
+func TestLimitDownloadRateStopsOnCancel(t *testing.T) {
+	srv := rateLimitServer(t, randomBytes(64<<10))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := GetCtx[[]byte](New(LimitDownloadRate(1<<10)), ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request took %v after cancellation", elapsed)
+	}
+}
+
+func TestLimitRateZeroIsUnlimited(t *testing.T) {
+	payload := randomBytes(256 << 10)
+	srv := rateLimitServer(t, payload)
+
+	start := time.Now()
+	res, err := Post[[]byte, []byte](New(LimitUploadRate(0)), srv.URL, payload, LimitDownloadRate(-1))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !bytes.Equal(res, payload) {
+		t.Fatalf("body has %d bytes", len(res))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %v without a limit", elapsed)
+	}
+}
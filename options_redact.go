@@ -0,0 +1,339 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const redactedValue = "REDACTED"
+
+// defaultRedactedHeaders are always masked in logs and dumps.
+var defaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization"}
+
+// RedactHeaders masks the values of the named headers wherever the client logs
+// or dumps requests and responses. Authorization, Cookie, Set-Cookie, X-Api-Key
+// and Proxy-Authorization are always masked; names are case-insensitive and
+// repeated calls add to the list.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: hide a custom token header from logs
+//
+//	c := httpx.New(httpx.
+//		Logger(slog.Default(), slog.LevelInfo).
+//		RedactHeaders("X-Session-Token"),
+//	)
+//	_ = c
+func RedactHeaders(names ...string) OptionBuilder {
+	return OptionBuilder{}.RedactHeaders(names...)
+}
+
+func (b OptionBuilder) RedactHeaders(names ...string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		r := c.redact.clone()
+		for _, name := range names {
+			r.headers = append(r.headers, http.CanonicalHeaderKey(name))
+		}
+		c.redact = r
+	}))
+}
+
+// RedactQuery masks the values of the named query parameters in logged and
+// dumped URLs. Names are case-insensitive and repeated calls add to the list.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: hide an API key passed in the query string
+//
+//	c := httpx.New(httpx.
+//		Logger(slog.Default(), slog.LevelInfo).
+//		RedactQuery("api_key", "signature"),
+//	)
+//	_ = c
+func RedactQuery(names ...string) OptionBuilder {
+	return OptionBuilder{}.RedactQuery(names...)
+}
+
+func (b OptionBuilder) RedactQuery(names ...string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		r := c.redact.clone()
+		for _, name := range names {
+			r.query = append(r.query, strings.ToLower(name))
+		}
+		c.redact = r
+	}))
+}
+
+// RedactJSONFields masks string, number, boolean and null values of the named
+// JSON object fields in dumped and logged bodies, at any nesting depth. Names
+// are case-insensitive and repeated calls add to the list.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: hide credentials in dumped JSON bodies
+//
+//	var buf bytes.Buffer
+//	c := httpx.New(httpx.
+//		DumpEachRequestTo(&buf).
+//		RedactJSONFields("password", "access_token"),
+//	)
+//	_ = c
+func RedactJSONFields(names ...string) OptionBuilder {
+	return OptionBuilder{}.RedactJSONFields(names...)
+}
+
+func (b OptionBuilder) RedactJSONFields(names ...string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		r := c.redact.clone()
+		r.json = append(r.json, names...)
+		quoted := make([]string, len(r.json))
+		for i, name := range r.json {
+			quoted[i] = regexp.QuoteMeta(name)
+		}
+		r.jsonPattern = regexp.MustCompile(`"(?i:` + strings.Join(quoted, "|") + `)"\s*:\s*(?:"(?:[^"\\]|\\.)*"|[-+.\w]+)`)
+		c.redact = r
+	}))
+}
+
+// redaction is shared by clients cloned from each other, so it is copied
+// rather than modified when options change it. A nil *redaction applies the
+// defaults.
+type redaction struct {
+	headers     []string
+	query       []string
+	json        []string
+	jsonPattern *regexp.Regexp
+}
+
+func (r *redaction) clone() *redaction {
+	if r == nil {
+		return &redaction{}
+	}
+	return &redaction{
+		headers:     slices.Clip(r.headers),
+		query:       slices.Clip(r.query),
+		json:        slices.Clip(r.json),
+		jsonPattern: r.jsonPattern,
+	}
+}
+
+// withHeaders returns r with the extra header names added.
+func (r *redaction) withHeaders(names []string) *redaction {
+	if len(names) == 0 {
+		return r
+	}
+	cp := r.clone()
+	cp.headers = append(cp.headers, names...)
+	return cp
+}
+
+func (r *redaction) redactsHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if slices.Contains(defaultRedactedHeaders, name) {
+		return true
+	}
+	return r != nil && slices.Contains(r.headers, name)
+}
+
+func (r *redaction) redactsQuery(name string) bool {
+	return r != nil && slices.Contains(r.query, strings.ToLower(name))
+}
+
+// header returns a copy of h with redacted values masked.
+func (r *redaction) header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for key, values := range h {
+		if r.redactsHeader(key) {
+			values = []string{redactedValue}
+		}
+		out[key] = slices.Clone(values)
+	}
+	return out
+}
+
+// url renders u with credentials and redacted query values masked. The order
+// and encoding of the remaining query parameters are preserved.
+func (r *redaction) url(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	masked := *u
+	if masked.User != nil {
+		masked.User = url.UserPassword(redactedValue, redactedValue)
+	}
+	masked.RawQuery = r.rawQuery(masked.RawQuery)
+	return masked.String()
+}
+
+func (r *redaction) rawQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	parts := strings.Split(raw, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && r.redactsQuery(name) {
+			parts[i] = key + "=" + redactedValue
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// body masks the configured JSON fields in body.
+func (r *redaction) body(body []byte) []byte {
+	if r == nil || r.jsonPattern == nil {
+		return body
+	}
+	return r.jsonPattern.ReplaceAllFunc(body, func(field []byte) []byte {
+		name, _, _ := bytes.Cut(field, []byte(":"))
+		return slices.Concat(name, []byte(`:"`+redactedValue+`"`))
+	})
+}
+
+// dump masks one line of HTTP/1 or HTTP/2 dump output: the request target's
+// query, header values and JSON fields in bodies.
+func (r *redaction) dump(line []byte) []byte {
+	text := string(line)
+	content := strings.TrimRight(text, "\r\n")
+	eol := text[len(content):]
+
+	if method, rest, ok := strings.Cut(content, " "); ok && isToken(method) {
+		if target, proto, ok := strings.Cut(rest, " "); ok && strings.HasPrefix(proto, "HTTP/") {
+			return []byte(method + " " + r.target(target) + " " + proto + eol)
+		}
+	}
+	if value, ok := strings.CutPrefix(content, ":path: "); ok {
+		return []byte(":path: " + r.target(value) + eol)
+	}
+	if name, _, ok := strings.Cut(content, ":"); ok && isToken(name) {
+		if r.redactsHeader(name) {
+			return []byte(name + ": " + redactedValue + eol)
+		}
+		return line
+	}
+	return r.body(line)
+}
+
+func (r *redaction) target(target string) string {
+	path, query, ok := strings.Cut(target, "?")
+	if !ok {
+		return target
+	}
+	return path + "?" + r.rawQuery(query)
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > unicode.MaxASCII || !(c == '-' || c == '_' || c == '.' || c == '!' || c == '#' || c == '$' || c == '%' ||
+			c == '&' || c == '\'' || c == '*' || c == '+' || c == '^' || c == '`' || c == '|' || c == '~' ||
+			'0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// maxPendingDump bounds how much of an unterminated line redactWriter holds
+// back; longer lines, typically bodies, are passed on in pieces.
+const maxPendingDump = 64 << 10
+
+// redactWriter masks dump output line by line before writing it to w. Dumps
+// arrive in fragments, so incomplete lines are held back until their newline
+// or an explicit flush at the end of the exchange.
+type redactWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	redact  func() *redaction
+	pending []byte
+}
+
+func newRedactWriter(w io.Writer, redact func() *redaction) *redactWriter {
+	return &redactWriter{w: w, redact: redact}
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	end := bytes.LastIndexByte(w.pending, '\n') + 1
+	if end == 0 && len(w.pending) > maxPendingDump {
+		end = len(w.pending)
+	}
+	if end == 0 {
+		return len(p), nil
+	}
+	err := w.write(w.pending[:end])
+	w.pending = append(w.pending[:0], w.pending[end:]...)
+	return len(p), err
+}
+
+// Flush writes any held back partial line.
+func (w *redactWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	err := w.write(w.pending)
+	w.pending = w.pending[:0]
+	return err
+}
+
+func (w *redactWriter) write(p []byte) error {
+	redact := w.redact()
+	var out []byte
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+		}
+		out = append(out, redact.dump(line)...)
+		p = p[len(line):]
+	}
+	_, err := w.w.Write(out)
+	return err
+}
+
+// redactDump masks a complete dump, such as the one returned by Response.Dump.
+func redactDump(dump string, redact *redaction) string {
+	var buf bytes.Buffer
+	w := newRedactWriter(&buf, func() *redaction { return redact })
+	_, _ = w.Write([]byte(dump))
+	_ = w.Flush()
+	return buf.String()
+}
+
+// traceRedaction reads HTTP_TRACE_REDACT, which controls masking of the dumps
+// HTTP_TRACE enables. Unset or a true value keeps the client's redaction, a
+// false value ("0", "false", "off") disables masking, and anything else is
+// taken as a comma-separated list of extra header names to mask.
+func traceRedaction() ([]string, bool) {
+	value, ok := os.LookupEnv("HTTP_TRACE_REDACT")
+	if !ok {
+		return nil, true
+	}
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "1", "true", "on", "yes":
+		return nil, true
+	case "0", "false", "off", "no":
+		return nil, false
+	}
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	return names, true
+}
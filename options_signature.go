@@ -0,0 +1,849 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// SignatureAlgorithm names an RFC 9421 HTTP message signature algorithm.
+type SignatureAlgorithm string
+
+const (
+	SignatureEd25519         SignatureAlgorithm = "ed25519"
+	SignatureECDSAP256SHA256 SignatureAlgorithm = "ecdsa-p256-sha256"
+	SignatureRSAPSSSHA512    SignatureAlgorithm = "rsa-pss-sha512"
+	SignatureHMACSHA256      SignatureAlgorithm = "hmac-sha256"
+)
+
+// DigestAlgorithm names an RFC 9530 digest algorithm.
+type DigestAlgorithm string
+
+const (
+	DigestSHA256 DigestAlgorithm = "sha-256"
+	DigestSHA512 DigestAlgorithm = "sha-512"
+)
+
+// ErrSignatureMismatch reports a message signature or content digest that does not verify.
+var ErrSignatureMismatch = errors.New("httpx: message signature mismatch")
+
+// signatureNow is swapped in tests to produce deterministic created parameters.
+var signatureNow = time.Now
+
+var defaultSignatureComponents = []string{"@method", "@target-uri", "content-digest"}
+
+// MessageSignature signs every attempt with an RFC 9421 HTTP message signature.
+// Components are derived component names (such as @method, @target-uri,
+// @authority, @path and @query) or lowercase header names; they default to
+// @method, @target-uri and content-digest. When content-digest is covered and
+// not already set, a sha-256 Content-Digest is computed from the request body.
+// The key is an ed25519.PrivateKey, *ecdsa.PrivateKey, *rsa.PrivateKey or an
+// HMAC secret as []byte, matching alg.
+// @group Auth
+//
+// Applies to client configuration only.
+// Example: sign requests with ed25519
+//
+//	_, priv, _ := ed25519.GenerateKey(nil)
+//	c := httpx.New(httpx.MessageSignature("partner-key", httpx.SignatureEd25519, priv,
+//		"@method", "@target-uri", "content-type", "content-digest",
+//	))
+//	res, _ := httpx.Post[map[string]any, map[string]any](c, "https://httpbin.org/post", map[string]any{"amount": 10})
+//	httpx.Dump(res) // dumps map[string]any
+//	// #map[string]interface {} {
+//	//   headers => #map[string]interface {} {
+//	//     Signature => "sig1=:<signature>:" #string
+//	//     Signature-Input => "sig1=(\"@method\" \"@target-uri\" \"content-type\" \"content-digest\");created=<unix>;keyid=\"partner-key\";alg=\"ed25519\"" #string
+//	//   }
+//	// }
+func MessageSignature(keyID string, alg SignatureAlgorithm, key any, components ...string) OptionBuilder {
+	return OptionBuilder{}.MessageSignature(keyID, alg, key, components...)
+}
+
+func (b OptionBuilder) MessageSignature(keyID string, alg SignatureAlgorithm, key any, components ...string) OptionBuilder {
+	if len(components) == 0 {
+		components = defaultSignatureComponents
+	}
+	signer := &messageSigner{keyID: keyID, alg: alg, key: key, components: components}
+	return b.add(clientOnly(func(c *Client) {
+		c.req.Transport.WrapRoundTripFunc(signer.wrap)
+	}))
+}
+
+// ContentDigest sets an RFC 9530 Content-Digest header computed from the request body.
+// Defaults to sha-256 when no algorithms are given.
+// @group Auth
+//
+// Applies to client configuration only.
+// Example: send a content digest
+//
+//	c := httpx.New()
+//	res, _ := httpx.Post[string, map[string]any](c, "https://httpbin.org/post", "hello",
+//		httpx.ContentDigest(httpx.DigestSHA256, httpx.DigestSHA512),
+//	)
+//	httpx.Dump(res) // dumps map[string]any
+//	// #map[string]interface {} {
+//	//   headers => #map[string]interface {} {
+//	//     Content-Digest => "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:, sha-512=:<digest>:" #string
+//	//   }
+//	// }
+func ContentDigest(algs ...DigestAlgorithm) OptionBuilder {
+	return OptionBuilder{}.ContentDigest(algs...)
+}
+
+func (b OptionBuilder) ContentDigest(algs ...DigestAlgorithm) OptionBuilder {
+	if len(algs) == 0 {
+		algs = []DigestAlgorithm{DigestSHA256}
+	}
+	return b.add(clientOnly(func(c *Client) {
+		c.req.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+			return func(r *http.Request) (*http.Response, error) {
+				r = r.Clone(r.Context())
+				body, err := bufferRequestBody(r)
+				if err != nil {
+					return nil, err
+				}
+				value, err := contentDigest(body, algs)
+				if err != nil {
+					return nil, err
+				}
+				r.Header.Set("Content-Digest", value)
+				return rt.RoundTrip(r)
+			}
+		})
+	}))
+}
+
+// VerifyRequestSignature verifies the RFC 9421 signature created with keyID on an incoming request.
+// Covered Content-Digest headers are checked against the body, which is restored for later reads.
+// The key is the public key (or HMAC secret) matching alg.
+// @group Auth
+//
+// Example: verify in a test server
+//
+//	pub, priv, _ := ed25519.GenerateKey(nil)
+//	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		if err := httpx.VerifyRequestSignature(r, "partner-key", httpx.SignatureEd25519, pub); err != nil {
+//			w.WriteHeader(http.StatusUnauthorized)
+//		}
+//	}))
+//	defer srv.Close()
+//	c := httpx.New(httpx.MessageSignature("partner-key", httpx.SignatureEd25519, priv))
+//	_, err := httpx.Get[string](c, srv.URL)
+//	fmt.Println(err == nil)
+//	// true
+func VerifyRequestSignature(r *http.Request, keyID string, alg SignatureAlgorithm, key any) error {
+	if r == nil {
+		return errors.New("httpx: nil request")
+	}
+	body, err := restoreBody(&r.Body)
+	if err != nil {
+		return err
+	}
+	return verifyMessageSignature(signatureMessage{req: r, body: body}, keyID, alg, key)
+}
+
+// VerifyResponseSignature verifies the RFC 9421 signature created with keyID on a response.
+// Components marked with ;req are resolved against resp.Request. Covered
+// Content-Digest headers are checked against the body, which is restored for later reads.
+// @group Auth
+//
+// Example: verify a signed response
+//
+//	pub, _, _ := ed25519.GenerateKey(nil)
+//	r := httpx.New().Req().R().SetURL("https://api.example.com/payments/1")
+//	r.Method = http.MethodGet
+//	_, resp, err := httpx.Do[string](r)
+//	if err != nil {
+//		return
+//	}
+//	fmt.Println(httpx.VerifyResponseSignature(resp.Response, "server-key", httpx.SignatureEd25519, pub))
+func VerifyResponseSignature(resp *http.Response, keyID string, alg SignatureAlgorithm, key any) error {
+	if resp == nil {
+		return errors.New("httpx: nil response")
+	}
+	body, err := restoreBody(&resp.Body)
+	if err != nil {
+		return err
+	}
+	return verifyMessageSignature(signatureMessage{req: resp.Request, resp: resp, body: body}, keyID, alg, key)
+}
+
+type messageSigner struct {
+	keyID      string
+	alg        SignatureAlgorithm
+	key        any
+	components []string
+}
+
+func (s *messageSigner) wrap(rt http.RoundTripper) req.HttpRoundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		if err := s.sign(r, signatureNow()); err != nil {
+			return nil, err
+		}
+		return rt.RoundTrip(r)
+	}
+}
+
+func (s *messageSigner) sign(r *http.Request, now time.Time) error {
+	covers := func(name string) bool {
+		for _, c := range s.components {
+			if c == name {
+				return true
+			}
+		}
+		return false
+	}
+	if covers("content-digest") && r.Header.Get("Content-Digest") == "" {
+		body, err := bufferRequestBody(r)
+		if err != nil {
+			return err
+		}
+		value, _ := contentDigest(body, []DigestAlgorithm{DigestSHA256})
+		r.Header.Set("Content-Digest", value)
+	}
+
+	ids := make([]string, len(s.components))
+	for i, c := range s.components {
+		ids[i] = strconv.Quote(strings.ToLower(c))
+	}
+	params := fmt.Sprintf("(%s);created=%d;keyid=%s", strings.Join(ids, " "), now.Unix(), strconv.Quote(s.keyID))
+	if s.alg != "" {
+		params += ";alg=" + strconv.Quote(string(s.alg))
+	}
+
+	msg := signatureMessage{req: r}
+	base, err := signatureBase(msg, params)
+	if err != nil {
+		return err
+	}
+	sig, err := signatureSign(s.alg, s.key, []byte(base))
+	if err != nil {
+		return err
+	}
+
+	label := nextSignatureLabel(r.Header.Get("Signature-Input"))
+	appendDictionaryHeader(r.Header, "Signature-Input", label+"="+params)
+	appendDictionaryHeader(r.Header, "Signature", label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+func nextSignatureLabel(existing string) string {
+	for i := 1; ; i++ {
+		label := "sig" + strconv.Itoa(i)
+		if !strings.Contains(existing, label+"=") {
+			return label
+		}
+	}
+}
+
+func appendDictionaryHeader(h http.Header, name, member string) {
+	if existing := h.Get(name); existing != "" {
+		h.Set(name, existing+", "+member)
+		return
+	}
+	h.Set(name, member)
+}
+
+// signatureMessage is the request or response a signature base is built from.
+// For responses, req is the originating request used by ;req components.
+type signatureMessage struct {
+	req  *http.Request
+	resp *http.Response
+	body []byte
+}
+
+// signatureBase builds the RFC 9421 signature base for the serialized inner list params.
+func signatureBase(msg signatureMessage, params string) (string, error) {
+	list, err := parseSFInnerList(params)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, item := range list.items {
+		value, err := msg.componentValue(item)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(item.raw)
+		b.WriteString(": ")
+		b.WriteString(value)
+		b.WriteByte('\n')
+	}
+	b.WriteString(`"@signature-params": `)
+	b.WriteString(params)
+	return b.String(), nil
+}
+
+func (m signatureMessage) componentValue(item sfItem) (string, error) {
+	name := item.value
+	_, fromReq := item.params["req"]
+	useResp := m.resp != nil && !fromReq
+	if fromReq && m.req == nil {
+		return "", fmt.Errorf("httpx: component %s requires the originating request", item.raw)
+	}
+	if !strings.HasPrefix(name, "@") {
+		var header http.Header
+		switch {
+		case useResp:
+			header = m.resp.Header
+		case m.req != nil:
+			header = m.req.Header
+		}
+		values, ok := header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return "", fmt.Errorf("httpx: signature component %q not present", name)
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.TrimSpace(v)
+		}
+		return strings.Join(trimmed, ", "), nil
+	}
+	if name == "@status" {
+		if !useResp {
+			return "", errors.New("httpx: @status is only valid for responses")
+		}
+		return strconv.Itoa(m.resp.StatusCode), nil
+	}
+	r := m.req
+	if r == nil {
+		return "", fmt.Errorf("httpx: component %s requires a request", name)
+	}
+	scheme := requestScheme(r)
+	switch name {
+	case "@method":
+		return strings.ToUpper(r.Method), nil
+	case "@scheme":
+		return scheme, nil
+	case "@authority":
+		return requestAuthority(r, scheme), nil
+	case "@target-uri":
+		return scheme + "://" + requestAuthority(r, scheme) + requestTarget(r), nil
+	case "@request-target":
+		return requestTarget(r), nil
+	case "@path":
+		path := r.URL.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		return path, nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	return "", fmt.Errorf("httpx: unsupported signature component %q", name)
+}
+
+func requestScheme(r *http.Request) string {
+	if r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func requestAuthority(r *http.Request, scheme string) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	host = strings.ToLower(host)
+	if (scheme == "https" && strings.HasSuffix(host, ":443")) || (scheme == "http" && strings.HasSuffix(host, ":80")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	return host
+}
+
+func requestTarget(r *http.Request) string {
+	if r.RequestURI != "" {
+		return r.RequestURI
+	}
+	return r.URL.RequestURI()
+}
+
+func verifyMessageSignature(msg signatureMessage, keyID string, alg SignatureAlgorithm, key any) error {
+	var header http.Header
+	if msg.resp != nil {
+		header = msg.resp.Header
+	} else {
+		header = msg.req.Header
+	}
+	inputs, err := parseSFDictionary(header.Get("Signature-Input"))
+	if err != nil {
+		return fmt.Errorf("httpx: parse Signature-Input: %w", err)
+	}
+	sigs, err := parseSFDictionary(header.Get("Signature"))
+	if err != nil {
+		return fmt.Errorf("httpx: parse Signature: %w", err)
+	}
+	for label, input := range inputs {
+		if input.list == nil || input.list.params["keyid"] != keyID {
+			continue
+		}
+		if declared, ok := input.list.params["alg"]; ok && declared != string(alg) {
+			return fmt.Errorf("httpx: signature %s uses alg %q, want %q", label, declared, alg)
+		}
+		sigItem, ok := sigs[label]
+		if !ok || sigItem.item == nil || !sigItem.item.binary {
+			return fmt.Errorf("httpx: signature %s missing", label)
+		}
+		sig, err := base64.StdEncoding.DecodeString(sigItem.item.value)
+		if err != nil {
+			return fmt.Errorf("httpx: signature %s: %w", label, err)
+		}
+		for _, item := range input.list.items {
+			if item.value == "content-digest" {
+				if _, fromReq := item.params["req"]; !fromReq {
+					if err := verifyContentDigest(header.Get("Content-Digest"), msg.body); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		base, err := signatureBase(msg, input.raw)
+		if err != nil {
+			return err
+		}
+		return signatureVerify(alg, key, []byte(base), sig)
+	}
+	return fmt.Errorf("httpx: no signature with keyid %q", keyID)
+}
+
+func signatureSign(alg SignatureAlgorithm, key any, base []byte) ([]byte, error) {
+	switch alg {
+	case SignatureEd25519:
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			break
+		}
+		return ed25519.Sign(priv, base), nil
+	case SignatureECDSAP256SHA256:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			break
+		}
+		sum := sha256.Sum256(base)
+		r, s, err := ecdsa.Sign(rand.Reader, priv, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, 64)
+		r.FillBytes(out[:32])
+		s.FillBytes(out[32:])
+		return out, nil
+	case SignatureRSAPSSSHA512:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			break
+		}
+		sum := sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, priv, crypto.SHA512, sum[:], &rsa.PSSOptions{SaltLength: 64})
+	case SignatureHMACSHA256:
+		secret, ok := key.([]byte)
+		if !ok {
+			break
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+		return mac.Sum(nil), nil
+	default:
+		return nil, fmt.Errorf("httpx: unsupported signature algorithm %q", alg)
+	}
+	return nil, fmt.Errorf("httpx: key type %T does not match signature algorithm %q", key, alg)
+}
+
+func signatureVerify(alg SignatureAlgorithm, key any, base, sig []byte) error {
+	ok := false
+	switch alg {
+	case SignatureEd25519:
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			ok = ed25519.Verify(k, base, sig)
+		case ed25519.PrivateKey:
+			ok = ed25519.Verify(k.Public().(ed25519.PublicKey), base, sig)
+		default:
+			return fmt.Errorf("httpx: key type %T does not match signature algorithm %q", key, alg)
+		}
+	case SignatureECDSAP256SHA256:
+		var pub *ecdsa.PublicKey
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			pub = k
+		case *ecdsa.PrivateKey:
+			pub = &k.PublicKey
+		default:
+			return fmt.Errorf("httpx: key type %T does not match signature algorithm %q", key, alg)
+		}
+		if len(sig) != 64 || pub.Curve != elliptic.P256() {
+			return ErrSignatureMismatch
+		}
+		sum := sha256.Sum256(base)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		ok = ecdsa.Verify(pub, sum[:], r, s)
+	case SignatureRSAPSSSHA512:
+		var pub *rsa.PublicKey
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			pub = k
+		case *rsa.PrivateKey:
+			pub = &k.PublicKey
+		default:
+			return fmt.Errorf("httpx: key type %T does not match signature algorithm %q", key, alg)
+		}
+		sum := sha512.Sum512(base)
+		ok = rsa.VerifyPSS(pub, crypto.SHA512, sum[:], sig, &rsa.PSSOptions{SaltLength: 64}) == nil
+	case SignatureHMACSHA256:
+		secret, isBytes := key.([]byte)
+		if !isBytes {
+			return fmt.Errorf("httpx: key type %T does not match signature algorithm %q", key, alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+		ok = hmac.Equal(mac.Sum(nil), sig)
+	default:
+		return fmt.Errorf("httpx: unsupported signature algorithm %q", alg)
+	}
+	if !ok {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func contentDigest(body []byte, algs []DigestAlgorithm) (string, error) {
+	parts := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		h, err := newDigestHash(alg)
+		if err != nil {
+			return "", err
+		}
+		h.Write(body)
+		parts = append(parts, string(alg)+"=:"+base64.StdEncoding.EncodeToString(h.Sum(nil))+":")
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+func newDigestHash(alg DigestAlgorithm) (hash.Hash, error) {
+	switch alg {
+	case DigestSHA256:
+		return sha256.New(), nil
+	case DigestSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("httpx: unsupported digest algorithm %q", alg)
+}
+
+// verifyContentDigest checks every supported algorithm listed in a Content-Digest header.
+func verifyContentDigest(value string, body []byte) error {
+	digests, err := parseSFDictionary(value)
+	if err != nil {
+		return fmt.Errorf("httpx: parse Content-Digest: %w", err)
+	}
+	checked := false
+	for alg, member := range digests {
+		h, err := newDigestHash(DigestAlgorithm(alg))
+		if err != nil || member.item == nil || !member.item.binary {
+			continue
+		}
+		want, err := base64.StdEncoding.DecodeString(member.item.value)
+		if err != nil {
+			return fmt.Errorf("httpx: Content-Digest %s: %w", alg, err)
+		}
+		h.Write(body)
+		if !hmac.Equal(h.Sum(nil), want) {
+			return fmt.Errorf("%w: content digest %s", ErrSignatureMismatch, alg)
+		}
+		checked = true
+	}
+	if !checked {
+		return errors.New("httpx: no supported Content-Digest algorithm")
+	}
+	return nil
+}
+
+// bufferRequestBody reads an outgoing body into memory and replaces it with a replayable copy.
+func bufferRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("httpx: read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
+
+func restoreBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("httpx: read body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// sfItem is a parsed RFC 8941 item. raw keeps the original serialization
+// (including parameters) for use in signature bases.
+type sfItem struct {
+	value  string
+	binary bool
+	params map[string]string
+	raw    string
+}
+
+type sfInnerList struct {
+	items  []sfItem
+	params map[string]string
+}
+
+// sfMember is a dictionary member holding either an item or an inner list.
+type sfMember struct {
+	item *sfItem
+	list *sfInnerList
+	raw  string
+}
+
+type sfParser struct {
+	s   string
+	pos int
+}
+
+func parseSFDictionary(s string) (map[string]sfMember, error) {
+	p := &sfParser{s: s}
+	out := map[string]sfMember{}
+	p.skipSpace()
+	for p.pos < len(p.s) {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		start := p.pos
+		var member sfMember
+		if p.peek() == '=' {
+			p.pos++
+			start = p.pos
+			if p.peek() == '(' {
+				list, err := p.innerList()
+				if err != nil {
+					return nil, err
+				}
+				member.list = list
+			} else {
+				item, err := p.item()
+				if err != nil {
+					return nil, err
+				}
+				member.item = &item
+			}
+		} else {
+			params, err := p.params()
+			if err != nil {
+				return nil, err
+			}
+			member.item = &sfItem{value: "?1", params: params}
+		}
+		member.raw = p.s[start:p.pos]
+		out[key] = member
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, fmt.Errorf("expected ',' at %d", p.pos)
+		}
+		p.pos++
+		p.skipSpace()
+	}
+	return out, nil
+}
+
+func parseSFInnerList(s string) (*sfInnerList, error) {
+	p := &sfParser{s: s}
+	list, err := p.innerList()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected trailing data at %d", p.pos)
+	}
+	return list, nil
+}
+
+func (p *sfParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *sfParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("expected key at %d", p.pos)
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *sfParser) innerList() (*sfInnerList, error) {
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("expected '(' at %d", p.pos)
+	}
+	p.pos++
+	list := &sfInnerList{}
+	for {
+		p.skipSpace()
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+		if p.pos >= len(p.s) {
+			return nil, errors.New("unterminated inner list")
+		}
+		item, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+	}
+	params, err := p.params()
+	if err != nil {
+		return nil, err
+	}
+	list.params = params
+	return list, nil
+}
+
+func (p *sfParser) item() (sfItem, error) {
+	start := p.pos
+	var item sfItem
+	switch c := p.peek(); {
+	case c == '"':
+		v, err := p.quoted()
+		if err != nil {
+			return item, err
+		}
+		item.value = v
+	case c == ':':
+		end := strings.IndexByte(p.s[p.pos+1:], ':')
+		if end < 0 {
+			return item, errors.New("unterminated byte sequence")
+		}
+		item.value = p.s[p.pos+1 : p.pos+1+end]
+		item.binary = true
+		p.pos += end + 2
+	default:
+		for p.pos < len(p.s) && !strings.ContainsRune(" \t,;()=", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		if start == p.pos {
+			return item, fmt.Errorf("expected item at %d", p.pos)
+		}
+		item.value = p.s[start:p.pos]
+	}
+	params, err := p.params()
+	if err != nil {
+		return item, err
+	}
+	item.params = params
+	item.raw = p.s[start:p.pos]
+	return item, nil
+}
+
+func (p *sfParser) params() (map[string]string, error) {
+	params := map[string]string{}
+	for p.peek() == ';' {
+		p.pos++
+		p.skipSpace()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != '=' {
+			params[key] = "?1"
+			continue
+		}
+		p.pos++
+		if p.peek() == '"' {
+			v, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			params[key] = v
+			continue
+		}
+		start := p.pos
+		for p.pos < len(p.s) && !strings.ContainsRune(" \t,;()", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		params[key] = p.s[start:p.pos]
+	}
+	return params, nil
+}
+
+func (p *sfParser) quoted() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", errors.New("unterminated string")
+			}
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		case '"':
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errors.New("unterminated string")
+}
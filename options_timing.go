@@ -0,0 +1,104 @@
+package httpx
+
+import (
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Timing breaks down where the time of a request attempt went.
+// Phases that did not happen, such as DNS and connect on a reused connection,
+// are zero. Only Total is set unless tracing is enabled with Trace, TraceAll,
+// TimingHook or RecordHAR.
+// @group Observability
+type Timing struct {
+	// DNS is the time spent resolving the host.
+	DNS time.Duration
+	// Connect is the time spent establishing the TCP connection.
+	Connect time.Duration
+	// TLS is the time spent in the TLS handshake.
+	TLS time.Duration
+	// TTFB is the time from the start of the attempt to the first response byte.
+	TTFB time.Duration
+	// Total is the time the attempt took end to end.
+	Total time.Duration
+	// ConnReused reports whether the connection was reused from the pool.
+	ConnReused bool
+	// RemoteAddr is the address of the server the connection went to.
+	RemoteAddr string
+}
+
+// TimingOf returns the timing breakdown of the attempt that produced resp.
+// @group Observability
+//
+// Example: read timings from a raw response
+//
+//	c := httpx.New(httpx.TraceAll())
+//	_, resp, _ := httpx.Do[string](c.Req().R().SetURL("https://httpbin.org/get"))
+//	timing := httpx.TimingOf(resp)
+//	fmt.Println(timing.DNS, timing.TLS, timing.TTFB, timing.Total)
+func TimingOf(resp *req.Response) Timing {
+	if resp == nil || resp.Request == nil {
+		return Timing{}
+	}
+	info := resp.TraceInfo()
+	timing := Timing{
+		DNS:        info.DNSLookupTime,
+		Connect:    info.TCPConnectTime,
+		TLS:        info.TLSHandshakeTime,
+		Total:      info.TotalTime,
+		ConnReused: info.IsConnReused,
+	}
+	if info.FirstResponseTime > 0 {
+		// Measured against the same start as TotalTime so TTFB never exceeds it.
+		timing.TTFB = info.TotalTime - info.ResponseTime
+	}
+	if info.RemoteAddr != nil {
+		timing.RemoteAddr = info.RemoteAddr.String()
+	}
+	if timing.Total == 0 && resp.Response != nil {
+		timing.Total = resp.TotalTime()
+	}
+	return timing
+}
+
+// TimingHook enables tracing and calls hook with the timing of every attempt,
+// including retries and attempts that failed before a response arrived.
+// @group Observability
+//
+// Applies to client configuration only.
+// Example: feed latency breakdowns into metrics
+//
+//	c := httpx.New(httpx.TimingHook(func(resp *req.Response, timing httpx.Timing) {
+//		fmt.Printf("%s ttfb=%s total=%s reused=%v\n", timing.RemoteAddr, timing.TTFB, timing.Total, timing.ConnReused)
+//	}))
+//	_ = c
+func TimingHook(hook func(*req.Response, Timing)) OptionBuilder {
+	return OptionBuilder{}.TimingHook(hook)
+}
+
+func (b OptionBuilder) TimingHook(hook func(*req.Response, Timing)) OptionBuilder {
+	if hook == nil {
+		return b
+	}
+	return b.add(clientOnly(func(c *Client) {
+		c.req.EnableTraceAll()
+		c.req.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+			return func(r *req.Request) (*req.Response, error) {
+				resp, err := rt.RoundTrip(r)
+				traced := timingResponse(r, resp)
+				hook(traced, TimingOf(traced))
+				return resp, err
+			}
+		})
+	}))
+}
+
+// timingResponse returns resp, or a response carrying only r when the
+// attempt failed before one was built, so TimingOf can still read the trace.
+func timingResponse(r *req.Request, resp *req.Response) *req.Response {
+	if resp == nil {
+		return &req.Response{Request: r}
+	}
+	return resp
+}
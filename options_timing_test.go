@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+func TestTimingHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var timings []Timing
+	c := New(TimingHook(func(resp *req.Response, timing Timing) {
+		if resp == nil || resp.Request == nil {
+			t.Errorf("hook called without a response")
+		}
+		timings = append(timings, timing)
+	}))
+	for range 2 {
+		if _, err := Get[string](c, srv.URL); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	if len(timings) != 2 {
+		t.Fatalf("timings = %d", len(timings))
+	}
+	first, second := timings[0], timings[1]
+	if first.ConnReused || first.Connect <= 0 || first.TTFB < 5*time.Millisecond || first.Total < first.TTFB {
+		t.Fatalf("unexpected first timing: %+v", first)
+	}
+	if first.RemoteAddr != srv.Listener.Addr().String() {
+		t.Fatalf("remote addr = %q", first.RemoteAddr)
+	}
+	if !second.ConnReused || second.Connect != 0 {
+		t.Fatalf("expected reused connection: %+v", second)
+	}
+
+	var failed []Timing
+	_, err := Get[string](New(), "http://127.0.0.1:1/", TimingHook(func(_ *req.Response, timing Timing) {
+		failed = append(failed, timing)
+	}))
+	if err == nil || len(failed) != 1 {
+		t.Fatalf("expected a timing for the failed attempt: %v %v", err, failed)
+	}
+}
+
+func TestHTTPErrorTiming(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	for name, c := range map[string]*Client{"traced": New(TraceAll()), "untraced": New()} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Get[string](c, srv.URL)
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("expected HTTPError, got %v", err)
+			}
+			if httpErr.Timing.Total <= 0 {
+				t.Fatalf("missing total: %+v", httpErr.Timing)
+			}
+			if traced := name == "traced"; traced != (httpErr.Timing.RemoteAddr != "") {
+				t.Fatalf("unexpected breakdown: %+v", httpErr.Timing)
+			}
+		})
+	}
+	if got := TimingOf(nil); got != (Timing{}) {
+		t.Fatalf("TimingOf(nil) = %+v", got)
+	}
+}
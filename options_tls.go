@@ -0,0 +1,227 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/imroc/req/v3"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientCert presents a client certificate loaded from PEM files for mutual TLS.
+// A certificate that fails to load is reported as an error on every request.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets.
+// Example: mutual TLS with certificate files
+//
+//	c := httpx.New(httpx.ClientCert("client.crt", "client.key"))
+//	_ = c
+func ClientCert(certFile, keyFile string) OptionBuilder {
+	return OptionBuilder{}.ClientCert(certFile, keyFile)
+}
+
+func (b OptionBuilder) ClientCert(certFile, keyFile string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			failRequests(c, fmt.Errorf("httpx: load client certificate: %w", err))
+			return
+		}
+		addClientCert(c, cert)
+	}))
+}
+
+// ClientCertPEM presents a client certificate from PEM-encoded bytes for mutual TLS.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets.
+// Example: mutual TLS with in-memory PEM
+//
+//	certPEM, _ := os.ReadFile("client.crt")
+//	keyPEM, _ := os.ReadFile("client.key")
+//	c := httpx.New(httpx.ClientCertPEM(certPEM, keyPEM))
+//	_ = c
+func ClientCertPEM(certPEM, keyPEM []byte) OptionBuilder {
+	return OptionBuilder{}.ClientCertPEM(certPEM, keyPEM)
+}
+
+func (b OptionBuilder) ClientCertPEM(certPEM, keyPEM []byte) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			failRequests(c, fmt.Errorf("httpx: parse client certificate: %w", err))
+			return
+		}
+		addClientCert(c, cert)
+	}))
+}
+
+// ClientCertPKCS12 presents a client certificate from a PKCS#12 (.p12/.pfx) bundle.
+// Intermediate certificates in the bundle are sent along with the leaf.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets.
+// Example: mutual TLS with a PKCS#12 bundle
+//
+//	pfx, _ := os.ReadFile("client.p12")
+//	c := httpx.New(httpx.ClientCertPKCS12(pfx, "changeit"))
+//	_ = c
+func ClientCertPKCS12(pfxData []byte, password string) OptionBuilder {
+	return OptionBuilder{}.ClientCertPKCS12(pfxData, password)
+}
+
+func (b OptionBuilder) ClientCertPKCS12(pfxData []byte, password string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		key, leaf, chain, err := pkcs12.DecodeChain(pfxData, password)
+		if err != nil {
+			failRequests(c, fmt.Errorf("httpx: decode PKCS#12 client certificate: %w", err))
+			return
+		}
+		cert := tls.Certificate{
+			Certificate: [][]byte{leaf.Raw},
+			PrivateKey:  key,
+			Leaf:        leaf,
+		}
+		for _, ca := range chain {
+			cert.Certificate = append(cert.Certificate, ca.Raw)
+		}
+		addClientCert(c, cert)
+	}))
+}
+
+// RootCAs trusts only the given PEM-encoded CA certificates when verifying servers.
+// The system roots are not consulted once RootCAs is set; calling it again adds
+// to the same pool.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets.
+// Example: trust a private CA
+//
+//	caPEM, _ := os.ReadFile("ca.pem")
+//	c := httpx.New(httpx.RootCAs(caPEM))
+//	_ = c
+func RootCAs(pem ...[]byte) OptionBuilder {
+	return OptionBuilder{}.RootCAs(pem...)
+}
+
+func (b OptionBuilder) RootCAs(pem ...[]byte) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.req.GetTLSClientConfig()
+		pool := x509.NewCertPool()
+		if cfg.RootCAs != nil {
+			pool = cfg.RootCAs.Clone()
+		}
+		for _, data := range pem {
+			if !pool.AppendCertsFromPEM(data) {
+				failRequests(c, errors.New("httpx: RootCAs: no certificates found in PEM data"))
+				return
+			}
+		}
+		cfg.RootCAs = pool
+	}))
+}
+
+// MinTLSVersion sets the minimum TLS version, such as tls.VersionTLS12.
+// With a TLSFingerprint preset the version offered is part of the fingerprint,
+// so connections that negotiate a lower version are rejected after the handshake.
+// @group TLS
+//
+// Applies to client configuration only.
+// Example: require TLS 1.3
+//
+//	c := httpx.New(httpx.MinTLSVersion(tls.VersionTLS13))
+//	_ = c
+func MinTLSVersion(version uint16) OptionBuilder {
+	return OptionBuilder{}.MinTLSVersion(version)
+}
+
+func (b OptionBuilder) MinTLSVersion(version uint16) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.req.GetTLSClientConfig().MinVersion = version
+	}))
+}
+
+// CipherSuites restricts the TLS 1.0-1.2 cipher suites, as in tls.Config.CipherSuites.
+// With a TLSFingerprint preset the offered list is part of the fingerprint, so
+// connections that negotiate a suite outside the list are rejected after the handshake.
+// @group TLS
+//
+// Applies to client configuration only.
+// Example: allow only ECDHE AES-GCM suites
+//
+//	c := httpx.New(httpx.CipherSuites(
+//		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+//		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+//	))
+//	_ = c
+func CipherSuites(ids ...uint16) OptionBuilder {
+	return OptionBuilder{}.CipherSuites(ids...)
+}
+
+func (b OptionBuilder) CipherSuites(ids ...uint16) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.req.GetTLSClientConfig().CipherSuites = append([]uint16(nil), ids...)
+	}))
+}
+
+// ServerName overrides the name used for SNI and certificate verification.
+// @group TLS
+//
+// Applies to client configuration only.
+// Works together with the TLSFingerprint presets.
+// Example: connect by IP but verify the service name
+//
+//	c := httpx.New(httpx.ServerName("api.internal"))
+//	_ = c
+func ServerName(name string) OptionBuilder {
+	return OptionBuilder{}.ServerName(name)
+}
+
+func (b OptionBuilder) ServerName(name string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.req.GetTLSClientConfig().ServerName = name
+	}))
+}
+
+// InsecureSkipVerify disables server certificate verification.
+// This accepts any certificate presented by the server, including ones forged by
+// an attacker, so a warning is logged whenever it is applied. Use RootCAs to
+// trust a private CA instead.
+// @group TLS
+//
+// Applies to client configuration only.
+// Example: talk to a local self-signed server
+//
+//	c := httpx.New(httpx.InsecureSkipVerify())
+//	_ = c
+func InsecureSkipVerify() OptionBuilder {
+	return OptionBuilder{}.InsecureSkipVerify()
+}
+
+func (b OptionBuilder) InsecureSkipVerify() OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		c.req.GetLogger().Warnf("httpx: InsecureSkipVerify is enabled; TLS certificates will NOT be verified and connections can be intercepted")
+		c.req.GetTLSClientConfig().InsecureSkipVerify = true
+	}))
+}
+
+func addClientCert(c *Client, cert tls.Certificate) {
+	cfg := c.req.GetTLSClientConfig()
+	cfg.Certificates = append(slices.Clip(cfg.Certificates), cert)
+}
+
+// failRequests reports a configuration error from every request on the client,
+// since options have no way to return one.
+func failRequests(c *Client, err error) {
+	c.req.OnBeforeRequest(func(*req.Client, *req.Request) error {
+		return err
+	})
+}
@@ -0,0 +1,261 @@
+package httpx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (c testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	pair, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("key pair: %v", err)
+	}
+	return pair
+}
+
+// newTestCert issues a certificate signed by parent, or a self-signed CA when parent is nil.
+func newTestCert(t *testing.T, parent *testCert, cn string, dnsNames ...string) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	if len(dnsNames) > 0 && dnsNames[0] == "127.0.0.1" {
+		tmpl.DNSNames = nil
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// closeIdleOnCleanup closes the idle connections of the client it is applied
+// to when the test ends. Left open, they are closed in the background once the
+// server shuts down, racing with tests that replace crypto/rand.Reader.
+func closeIdleOnCleanup(t *testing.T) Option {
+	return clientOnly(func(c *Client) {
+		t.Cleanup(c.req.CloseIdleConnections)
+	})
+}
+
+// newMTLSServer starts a TLS server with the given certificate that requires a
+// client certificate issued by clientCA and echoes the client's common name.
+func newMTLSServer(t *testing.T, serverCert tls.Certificate, clientCA *testCert, maxVersion uint16) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "anonymous"
+		if len(r.TLS.PeerCertificates) > 0 {
+			name = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		_, _ = w.Write([]byte(name))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, MaxVersion: maxVersion}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA.cert)
+		srv.TLS.ClientCAs = pool
+		srv.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientCertAndRootCAs(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	client := newTestCert(t, &ca, "device-42")
+	srv := newMTLSServer(t, server.tlsCertificate(t), &ca, 0)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	_ = os.WriteFile(certFile, client.certPEM, 0o600)
+	_ = os.WriteFile(keyFile, client.keyPEM, 0o600)
+
+	pfx, err := pkcs12.Modern.Encode(client.key, client.cert, []*x509.Certificate{ca.cert}, "secret")
+	if err != nil {
+		t.Fatalf("encode pkcs12: %v", err)
+	}
+
+	cases := map[string]OptionBuilder{
+		"files":              ClientCert(certFile, keyFile),
+		"pem":                ClientCertPEM(client.certPEM, client.keyPEM),
+		"pkcs12":             ClientCertPKCS12(pfx, "secret"),
+		"fingerprint before": TLSFingerprintChrome().ClientCertPEM(client.certPEM, client.keyPEM),
+		"fingerprint after":  ClientCertPEM(client.certPEM, client.keyPEM).TLSFingerprintFirefox(),
+		"fingerprint pkcs12": TLSFingerprintSafari().ClientCertPKCS12(pfx, "secret"),
+		"fingerprint edge":   ClientCertPEM(client.certPEM, client.keyPEM).TLSFingerprintEdge(),
+	}
+	for name, opt := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := New(opt.RootCAs(ca.certPEM), closeIdleOnCleanup(t))
+			res, err := Get[string](c, srv.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if res != "device-42" {
+				t.Fatalf("client identity = %q", res)
+			}
+		})
+	}
+
+	t.Run("untrusted", func(t *testing.T) {
+		c := New(ClientCertPEM(client.certPEM, client.keyPEM).TLSFingerprintChrome(), closeIdleOnCleanup(t))
+		if _, err := Get[string](c, srv.URL); err == nil {
+			t.Fatalf("expected unknown authority error")
+		}
+	})
+}
+
+func TestServerNameWithFingerprintPerRequest(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "api.internal")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, 0)
+
+	c := New(TLSFingerprintChrome().RootCAs(ca.certPEM), closeIdleOnCleanup(t))
+	if _, err := Get[string](c, srv.URL); err == nil {
+		t.Fatalf("expected name mismatch without ServerName")
+	}
+	res, err := Get[string](c, srv.URL, ServerName("api.internal"), closeIdleOnCleanup(t))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if res != "anonymous" {
+		t.Fatalf("response = %q", res)
+	}
+	if _, err := Get[string](c, srv.URL); err == nil {
+		t.Fatalf("per-request ServerName leaked into the base client")
+	}
+}
+
+func TestMinTLSVersion(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, tls.VersionTLS12)
+
+	for name, opt := range map[string]OptionBuilder{
+		"standard":    RootCAs(ca.certPEM),
+		"fingerprint": RootCAs(ca.certPEM).TLSFingerprintChrome(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Get[string](New(opt, closeIdleOnCleanup(t)), srv.URL); err != nil {
+				t.Fatalf("TLS 1.2 request failed: %v", err)
+			}
+			_, err := Get[string](New(opt.MinTLSVersion(tls.VersionTLS13), closeIdleOnCleanup(t)), srv.URL)
+			if err == nil {
+				t.Fatalf("expected TLS 1.3 requirement to fail against a TLS 1.2 server")
+			}
+		})
+	}
+}
+
+func TestCipherSuitesWithFingerprint(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, tls.VersionTLS12)
+
+	c := New(TLSFingerprintChrome().RootCAs(ca.certPEM).CipherSuites(tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA), closeIdleOnCleanup(t))
+	_, err := Get[string](c, srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "cipher suite") {
+		t.Fatalf("expected disallowed cipher suite error, got %v", err)
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	ca := newTestCert(t, nil, "test ca")
+	server := newTestCert(t, &ca, "server", "127.0.0.1")
+	srv := newMTLSServer(t, server.tlsCertificate(t), nil, 0)
+
+	var warnings []string
+	c := New(closeIdleOnCleanup(t))
+	c.req.SetLogger(&recordingLogger{warn: &warnings})
+	InsecureSkipVerify().applyClient(c)
+	TLSFingerprintChrome().applyClient(c)
+	if _, err := Get[string](c, srv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "InsecureSkipVerify") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
+
+func TestTLSOptionErrors(t *testing.T) {
+	cases := map[string]OptionBuilder{
+		"missing files": ClientCert("missing.crt", "missing.key"),
+		"bad pem":       ClientCertPEM([]byte("nope"), []byte("nope")),
+		"bad pkcs12":    ClientCertPKCS12([]byte("nope"), ""),
+		"bad root":      RootCAs([]byte("nope")),
+	}
+	for name, opt := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Get[string](New(opt), "https://127.0.0.1:1")
+			if err == nil || !strings.HasPrefix(err.Error(), "httpx: ") {
+				t.Fatalf("expected configuration error, got %v", err)
+			}
+		})
+	}
+}
+
+type recordingLogger struct {
+	warn *[]string
+}
+
+func (l *recordingLogger) Errorf(string, ...any) {}
+func (l *recordingLogger) Debugf(string, ...any) {}
+func (l *recordingLogger) Warnf(format string, v ...any) {
+	*l.warn = append(*l.warn, format)
+}
@@ -0,0 +1,817 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+const (
+	tusVersion          = "1.0.0"
+	defaultTusChunkSize = 4 << 20
+	// maxTusStalls is how many failed requests in a row an upload survives
+	// without the server accepting any new bytes.
+	maxTusStalls = 3
+	// statusChecksumMismatch is the tus status for a chunk whose
+	// Upload-Checksum did not match.
+	statusChecksumMismatch = 460
+)
+
+// tusRetryDelay is the pause before the first recovery of a failed chunk. It
+// doubles with every further failure.
+var tusRetryDelay = 500 * time.Millisecond
+
+// TusUpload uploads everything reader yields to a tus 1.0 server and returns
+// the URL of the upload. Chunks are sent with PATCH requests, and when one
+// fails the upload asks the server how much it received and continues from
+// there. A reader that implements io.Seeker is uploaded from its current
+// position and its size is announced up front; the length of other readers is
+// sent once they are drained.
+//
+// Request options apply to every request of the upload. UploadCallback,
+// UploadCallbackWithInterval and UploadProgress report the upload as a whole.
+// @group Resumable Uploads
+//
+// Example: upload a large file in 8 MiB chunks
+//
+//	f, _ := os.Open("/tmp/video.mp4")
+//	defer f.Close()
+//	c := httpx.New()
+//	uploadURL, _ := httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f,
+//		httpx.TusChunkSize(8<<20),
+//		httpx.TusMetadata(map[string]string{"filename": "video.mp4"}),
+//		httpx.UploadProgress(),
+//	)
+//	fmt.Println(uploadURL)
+//	// https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216
+func TusUpload(ctx context.Context, client *Client, endpoint string, reader io.Reader, opts ...Option) (string, error) {
+	if client == nil {
+		client = Default()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if reader == nil {
+		return "", errors.New("httpx: tus upload needs a reader")
+	}
+	client = client.clone()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.applyClient(client)
+	}
+	client.ownBodies()
+	cfg := client.tus
+	if cfg == nil {
+		cfg = &tusConfig{}
+	}
+	if cfg.checksum != "" {
+		if _, err := newDigestHash(cfg.checksum); err != nil {
+			return "", err
+		}
+	}
+	u := &tusUploader{
+		ctx:      ctx,
+		client:   client,
+		cfg:      cfg,
+		endpoint: endpoint,
+		reader:   reader,
+		opts:     opts,
+		size:     -1,
+		progress: &uploadProgress{id: progressIDs.Add(1)},
+	}
+	if err := u.measure(); err != nil {
+		return "", err
+	}
+	return u.run()
+}
+
+// TusTerminate deletes an unfinished upload from a tus server that supports
+// the termination extension. The server frees its storage and later requests
+// for the upload fail.
+// @group Resumable Uploads
+//
+// Example: abandon an upload
+//
+//	c := httpx.New()
+//	_ = httpx.TusTerminate(context.Background(), c, "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+func TusTerminate(ctx context.Context, client *Client, uploadURL string, opts ...Option) error {
+	if client == nil {
+		client = Default()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client = client.clone()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.applyClient(client)
+	}
+	client.ownBodies()
+	u := &tusUploader{ctx: ctx, client: client, opts: opts, progress: &uploadProgress{}}
+	resp, err := u.send(u.request(), methodDelete, uploadURL)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccessState() {
+		return client.mapError(resp)
+	}
+	return nil
+}
+
+// TusChunkSize sets how many bytes TusUpload sends per PATCH request. Each
+// chunk is held in memory so it can be sent again after a failure. The
+// default is 4 MiB.
+// @group Resumable Uploads
+//
+// Applies to TusUpload only.
+// Example: smaller chunks for slow links
+//
+//	f, _ := os.Open("/tmp/video.mp4")
+//	c := httpx.New()
+//	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f, httpx.TusChunkSize(1<<20))
+func TusChunkSize(n int64) OptionBuilder {
+	return OptionBuilder{}.TusChunkSize(n)
+}
+
+func (b OptionBuilder) TusChunkSize(n int64) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.tus.clone()
+		cfg.chunkSize = n
+		c.tus = cfg
+	}))
+}
+
+// TusMetadata adds Upload-Metadata pairs, such as the file name, to the
+// creation of a TusUpload. Repeated calls merge their pairs.
+// @group Resumable Uploads
+//
+// Applies to TusUpload only.
+// Example: name the upload
+//
+//	f, _ := os.Open("/tmp/video.mp4")
+//	c := httpx.New()
+//	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f,
+//		httpx.TusMetadata(map[string]string{"filename": "video.mp4", "filetype": "video/mp4"}),
+//	)
+func TusMetadata(metadata map[string]string) OptionBuilder {
+	return OptionBuilder{}.TusMetadata(metadata)
+}
+
+func (b OptionBuilder) TusMetadata(metadata map[string]string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.tus.clone()
+		merged := make(map[string]string, len(cfg.metadata)+len(metadata))
+		for k, v := range cfg.metadata {
+			merged[k] = v
+		}
+		for k, v := range metadata {
+			merged[k] = v
+		}
+		cfg.metadata = merged
+		c.tus = cfg
+	}))
+}
+
+// TusChecksum sends an Upload-Checksum with every chunk of a TusUpload, using
+// the tus checksum extension. The server rejects a chunk that was corrupted
+// on the way, and it is sent again. Before the upload starts, an OPTIONS
+// request checks that the server supports the extension and the algorithm;
+// if not, TusUpload fails without sending any data.
+// @group Resumable Uploads
+//
+// Applies to TusUpload only.
+// Example: verify each chunk with SHA-256
+//
+//	f, _ := os.Open("/tmp/video.mp4")
+//	c := httpx.New()
+//	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f, httpx.TusChecksum(httpx.DigestSHA256))
+func TusChecksum(alg DigestAlgorithm) OptionBuilder {
+	return OptionBuilder{}.TusChecksum(alg)
+}
+
+func (b OptionBuilder) TusChecksum(alg DigestAlgorithm) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.tus.clone()
+		cfg.checksum = alg
+		c.tus = cfg
+	}))
+}
+
+// TusResume remembers the URL of each TusUpload in store, keyed by the
+// endpoint and a fingerprint of the upload, so that uploading the same data
+// again, even from another process, continues where the last attempt
+// stopped. Files are fingerprinted by path, size and modification time; set
+// TusFingerprint for other readers. Entries are removed once an upload
+// completes.
+// @group Resumable Uploads
+//
+// Applies to TusUpload only.
+// Example: survive process restarts
+//
+//	store := httpx.NewTusFileStore("/var/lib/myapp/uploads.json")
+//	c := httpx.New(httpx.TusResume(store))
+//	f, _ := os.Open("/tmp/video.mp4")
+//	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", f)
+func TusResume(store TusStore) OptionBuilder {
+	return OptionBuilder{}.TusResume(store)
+}
+
+func (b OptionBuilder) TusResume(store TusStore) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.tus.clone()
+		cfg.store = store
+		c.tus = cfg
+	}))
+}
+
+// TusFingerprint identifies the data of a TusUpload for TusResume, such as a
+// content hash or a database ID. It is required to resume readers that are
+// not files.
+// @group Resumable Uploads
+//
+// Applies to TusUpload only.
+// Example: resume a generated stream
+//
+//	store := httpx.NewTusMemoryStore()
+//	c := httpx.New(httpx.TusResume(store))
+//	_, _ = httpx.TusUpload(context.Background(), c, "https://tus.example.com/files/", strings.NewReader("report"),
+//		httpx.TusFingerprint("report-2024-06"),
+//	)
+func TusFingerprint(fingerprint string) OptionBuilder {
+	return OptionBuilder{}.TusFingerprint(fingerprint)
+}
+
+func (b OptionBuilder) TusFingerprint(fingerprint string) OptionBuilder {
+	return b.add(clientOnly(func(c *Client) {
+		cfg := c.tus.clone()
+		cfg.fingerprint = fingerprint
+		c.tus = cfg
+	}))
+}
+
+// TusStore records the URLs of unfinished tus uploads by fingerprint.
+// Implementations must be safe for concurrent use.
+// @group Resumable Uploads
+type TusStore interface {
+	Get(fingerprint string) (uploadURL string, ok bool)
+	Set(fingerprint, uploadURL string) error
+	Delete(fingerprint string) error
+}
+
+// NewTusFileStore returns a TusStore kept as a JSON file at path, which is
+// created on first use.
+// @group Resumable Uploads
+//
+// Example: persist upload URLs
+//
+//	store := httpx.NewTusFileStore("/var/lib/myapp/uploads.json")
+//	_ = store.Set("fingerprint", "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+//	uploadURL, _ := store.Get("fingerprint")
+//	fmt.Println(uploadURL)
+//	// https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216
+func NewTusFileStore(path string) TusStore {
+	return &tusFileStore{path: path}
+}
+
+// NewTusMemoryStore returns a TusStore that lives as long as the process,
+// which lets uploads resume after a failed TusUpload call.
+// @group Resumable Uploads
+//
+// Example: resume within a process
+//
+//	store := httpx.NewTusMemoryStore()
+//	_ = store.Set("fingerprint", "https://tus.example.com/files/24e533e02ec3bc40c387f1a0e460e216")
+//	_, ok := store.Get("fingerprint")
+//	fmt.Println(ok)
+//	// true
+func NewTusMemoryStore() TusStore {
+	return &tusMemoryStore{urls: map[string]string{}}
+}
+
+type tusMemoryStore struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func (s *tusMemoryStore) Get(fingerprint string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.urls[fingerprint]
+	return u, ok
+}
+
+func (s *tusMemoryStore) Set(fingerprint, uploadURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.urls[fingerprint] = uploadURL
+	return nil
+}
+
+func (s *tusMemoryStore) Delete(fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.urls, fingerprint)
+	return nil
+}
+
+type tusFileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *tusFileStore) Get(fingerprint string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls, err := s.load()
+	if err != nil {
+		return "", false
+	}
+	u, ok := urls[fingerprint]
+	return u, ok
+}
+
+func (s *tusFileStore) Set(fingerprint, uploadURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls, err := s.load()
+	if err != nil {
+		return err
+	}
+	urls[fingerprint] = uploadURL
+	return s.save(urls)
+}
+
+func (s *tusFileStore) Delete(fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := urls[fingerprint]; !ok {
+		return nil
+	}
+	delete(urls, fingerprint)
+	return s.save(urls)
+}
+
+func (s *tusFileStore) load() (map[string]string, error) {
+	urls := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return urls, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &urls); err != nil {
+		return nil, fmt.Errorf("httpx: read tus store %s: %w", s.path, err)
+	}
+	return urls, nil
+}
+
+// save replaces the file in one rename, so a crash never leaves it torn.
+func (s *tusFileStore) save(urls map[string]string) error {
+	data, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(s.path), "."+filepath.Base(s.path)+"."+rand.Text()+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := commitFile(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// tusConfig is shared by clients until an option changes it.
+type tusConfig struct {
+	chunkSize   int64
+	metadata    map[string]string
+	checksum    DigestAlgorithm
+	store       TusStore
+	fingerprint string
+}
+
+func (t *tusConfig) clone() *tusConfig {
+	if t == nil {
+		return &tusConfig{}
+	}
+	cp := *t
+	return &cp
+}
+
+func (t *tusConfig) chunk() int64 {
+	if t.chunkSize <= 0 {
+		return defaultTusChunkSize
+	}
+	return t.chunkSize
+}
+
+type tusUploader struct {
+	ctx      context.Context
+	client   *Client
+	cfg      *tusConfig
+	endpoint string
+	reader   io.Reader
+	opts     []Option
+	progress *uploadProgress
+	// start is the position of a seekable reader when the upload began.
+	start int64
+	// size is the length of the upload, or -1 until the reader is drained.
+	size int64
+	// consumed counts the bytes taken from the reader.
+	consumed int64
+	key      string
+}
+
+// measure learns the size and fingerprint of the reader where possible.
+func (u *tusUploader) measure() error {
+	if s, ok := u.reader.(io.Seeker); ok {
+		start, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			end, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return err
+			}
+			if _, err := s.Seek(start, io.SeekStart); err != nil {
+				return err
+			}
+			u.start, u.size = start, end-start
+		}
+	}
+	fingerprint := u.cfg.fingerprint
+	if f, ok := u.reader.(*os.File); ok {
+		u.progress.info.FileName = filepath.Base(f.Name())
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() && fingerprint == "" {
+			path, _ := filepath.Abs(f.Name())
+			fingerprint = fmt.Sprintf("%s:%d:%d:%d", path, info.Size(), info.ModTime().UnixNano(), u.start)
+		}
+	}
+	if fingerprint != "" && u.cfg.store != nil {
+		u.key = u.endpoint + " " + fingerprint
+	}
+	u.progress.info.FileSize = max(u.size, 0)
+	return nil
+}
+
+func (u *tusUploader) run() (string, error) {
+	if u.cfg.checksum != "" {
+		if err := u.discoverChecksum(); err != nil {
+			return "", err
+		}
+	}
+	uploadURL, offset, err := u.resume()
+	if err != nil {
+		return "", err
+	}
+	if uploadURL == "" {
+		if uploadURL, err = u.create(); err != nil {
+			return "", err
+		}
+		if u.key != "" {
+			if err := u.cfg.store.Set(u.key, uploadURL); err != nil {
+				return uploadURL, err
+			}
+		}
+	}
+	if err := u.skip(offset); err != nil {
+		return uploadURL, err
+	}
+	u.progress.set(offset)
+	if err := u.upload(uploadURL, offset); err != nil {
+		return uploadURL, err
+	}
+	if u.key != "" {
+		if err := u.cfg.store.Delete(u.key); err != nil {
+			return uploadURL, err
+		}
+	}
+	u.progress.finish()
+	return uploadURL, nil
+}
+
+// discoverChecksum asks the server which extensions it supports, so that an
+// upload with TusChecksum fails before it starts when the server cannot
+// verify the algorithm.
+func (u *tusUploader) discoverChecksum() error {
+	resp, err := u.send(u.request(), methodOptions, u.endpoint)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccessState() {
+		return u.client.mapError(resp)
+	}
+	name := tusChecksumName(u.cfg.checksum)
+	if !tusListHas(resp.Header.Get("Tus-Extension"), "checksum") ||
+		!tusListHas(resp.Header.Get("Tus-Checksum-Algorithm"), name) {
+		return fmt.Errorf("httpx: tus server does not support checksum algorithm %s", name)
+	}
+	return nil
+}
+
+// resume looks up an earlier upload of the same data and asks the server
+// how much of it arrived. It returns an empty URL when there is nothing to
+// resume.
+func (u *tusUploader) resume() (string, int64, error) {
+	if u.key == "" {
+		return "", 0, nil
+	}
+	uploadURL, ok := u.cfg.store.Get(u.key)
+	if !ok {
+		return "", 0, nil
+	}
+	offset, length, status, err := u.head(uploadURL)
+	if status >= 400 && status < 500 {
+		// The server no longer knows the upload, so start a new one.
+		return "", 0, u.cfg.store.Delete(u.key)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if length >= 0 && u.size >= 0 && length != u.size {
+		return "", 0, u.cfg.store.Delete(u.key)
+	}
+	return uploadURL, offset, nil
+}
+
+// create registers the upload with the server and returns its URL.
+func (u *tusUploader) create() (string, error) {
+	r := u.request()
+	if u.size >= 0 {
+		r.SetHeader("Upload-Length", strconv.FormatInt(u.size, 10))
+	} else {
+		r.SetHeader("Upload-Defer-Length", "1")
+	}
+	if len(u.cfg.metadata) > 0 {
+		r.SetHeader("Upload-Metadata", tusMetadata(u.cfg.metadata))
+	}
+	resp, err := u.send(r, methodPost, u.endpoint)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", u.client.mapError(resp)
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", errors.New("httpx: tus server sent no upload Location")
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("httpx: invalid tus upload Location %q: %w", location, err)
+	}
+	return resp.Response.Request.URL.ResolveReference(ref).String(), nil
+}
+
+// head returns the offset of an upload and its length, or -1 when the length
+// is deferred. The status is 0 when no response arrived.
+func (u *tusUploader) head(uploadURL string) (offset, length int64, status int, err error) {
+	r := u.request()
+	r.SetHeader("Cache-Control", "no-store")
+	resp, err := u.send(r, methodHead, uploadURL)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if !resp.IsSuccessState() {
+		return 0, 0, resp.StatusCode, u.client.mapError(resp)
+	}
+	offset, err = strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, resp.StatusCode, fmt.Errorf("httpx: invalid tus Upload-Offset %q", resp.Header.Get("Upload-Offset"))
+	}
+	length = -1
+	if v := resp.Header.Get("Upload-Length"); v != "" {
+		if length, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, resp.StatusCode, fmt.Errorf("httpx: invalid tus Upload-Length %q", v)
+		}
+	}
+	return offset, length, resp.StatusCode, nil
+}
+
+// skip moves the reader to offset bytes past the start of the upload.
+func (u *tusUploader) skip(offset int64) error {
+	if s, ok := u.reader.(io.Seeker); ok && u.size >= 0 {
+		if _, err := s.Seek(u.start+offset, io.SeekStart); err != nil {
+			return err
+		}
+		u.consumed = offset
+		return nil
+	}
+	n, err := io.CopyN(io.Discard, u.reader, offset)
+	u.consumed = n
+	if err == io.EOF {
+		return fmt.Errorf("httpx: tus server has %d bytes but the reader ended after %d", offset, n)
+	}
+	return err
+}
+
+// upload sends the rest of the reader, chunk by chunk, from offset.
+func (u *tusUploader) upload(uploadURL string, offset int64) error {
+	buf := make([]byte, u.cfg.chunk())
+	for u.size < 0 || offset < u.size {
+		n, err := io.ReadFull(u.reader, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+		u.consumed += int64(n)
+		if u.size >= 0 && last && u.consumed < u.size {
+			return io.ErrUnexpectedEOF
+		}
+		if u.size >= 0 && u.consumed > u.size {
+			n -= int(u.consumed - u.size)
+			u.consumed = u.size
+		}
+		if offset, err = u.sendChunk(uploadURL, offset, buf[:n], last && u.size < 0); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+	}
+	return nil
+}
+
+// sendChunk makes sure the server holds chunk, which starts at offset, and
+// returns the offset after it. Failed requests are recovered by asking the
+// server how much arrived and sending the rest.
+func (u *tusUploader) sendChunk(uploadURL string, offset int64, chunk []byte, final bool) (int64, error) {
+	start, end := offset, offset+int64(len(chunk))
+	stalls := 0
+	for {
+		next, status, err := u.patch(uploadURL, offset, chunk[offset-start:], final)
+		if err == nil {
+			offset = next
+			if offset >= end {
+				return offset, nil
+			}
+			stalls = 0
+			continue
+		}
+		if !tusRecoverable(status, err) || u.ctx.Err() != nil {
+			return offset, err
+		}
+		stalls++
+		if stalls > maxTusStalls {
+			return offset, err
+		}
+		if err := sleepCtx(u.ctx, tusRetryDelay<<(stalls-1)); err != nil {
+			return offset, err
+		}
+		current, _, status, headErr := u.head(uploadURL)
+		if headErr != nil {
+			if !tusRecoverable(status, headErr) {
+				return offset, headErr
+			}
+			continue
+		}
+		if current < start || current > end {
+			return offset, fmt.Errorf("httpx: tus server offset %d is outside the chunk at %d-%d", current, start, end)
+		}
+		if current > offset {
+			stalls = 0
+		}
+		offset = current
+		u.progress.set(offset)
+	}
+}
+
+// patch sends body at offset and returns the offset the server reports. The
+// status is 0 when no response arrived.
+func (u *tusUploader) patch(uploadURL string, offset int64, body []byte, final bool) (int64, int, error) {
+	r := u.request()
+	r.SetHeader("Content-Type", "application/offset+octet-stream")
+	r.SetHeader("Upload-Offset", strconv.FormatInt(offset, 10))
+	if final {
+		r.SetHeader("Upload-Length", strconv.FormatInt(offset+int64(len(body)), 10))
+	}
+	if u.cfg.checksum != "" {
+		h, _ := newDigestHash(u.cfg.checksum)
+		h.Write(body)
+		r.SetHeader("Upload-Checksum", tusChecksumName(u.cfg.checksum)+" "+base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	}
+	r.SetBodyBytes(body)
+	// Count bytes as the transport reads them. Every attempt starts over.
+	r.GetBody = func() (io.ReadCloser, error) {
+		u.progress.set(offset)
+		return io.NopCloser(&uploadReader{r: bytes.NewReader(body), progress: u.progress}), nil
+	}
+	resp, err := u.send(r, methodPatch, uploadURL)
+	if err != nil {
+		return offset, 0, err
+	}
+	if !resp.IsSuccessState() {
+		return offset, resp.StatusCode, u.client.mapError(resp)
+	}
+	next, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || next < offset {
+		return offset, resp.StatusCode, fmt.Errorf("httpx: invalid tus Upload-Offset %q", resp.Header.Get("Upload-Offset"))
+	}
+	u.progress.set(next)
+	return next, resp.StatusCode, nil
+}
+
+func (u *tusUploader) request() *req.Request {
+	r := u.client.req.R()
+	r.SetContext(context.WithValue(u.ctx, uploadProgressKey{}, u.progress))
+	bindClient(r, u.client)
+	for _, opt := range u.opts {
+		if opt == nil {
+			continue
+		}
+		opt.applyRequest(r)
+	}
+	r.SetHeader("Tus-Resumable", tusVersion)
+	return r
+}
+
+func (u *tusUploader) send(r *req.Request, method, uploadURL string) (*req.Response, error) {
+	finish := u.client.startCall(r, method, uploadURL)
+	resp, err := send(r, method, uploadURL)
+	finish(resp, err)
+	return resp, err
+}
+
+// tusRecoverable reports whether a request that failed with err and status
+// may be repeated after checking the server's offset. Without a response the
+// server holds an unknown part of the chunk, which the check reveals.
+func tusRecoverable(status int, err error) bool {
+	switch {
+	case status == 0:
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	case status == http.StatusConflict,
+		status == statusChecksumMismatch,
+		status == http.StatusTooManyRequests,
+		status >= 500:
+		return true
+	}
+	return false
+}
+
+// tusChecksumName returns the name tus uses for alg, such as sha256.
+func tusChecksumName(alg DigestAlgorithm) string {
+	return strings.ToLower(strings.ReplaceAll(string(alg), "-", ""))
+}
+
+// tusListHas reports whether the comma-separated header value lists name.
+func tusListHas(value, name string) bool {
+	for _, item := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// tusMetadata encodes the Upload-Metadata header, with keys in a stable order.
+func tusMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if metadata[k] == "" {
+			pairs = append(pairs, k)
+			continue
+		}
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(metadata[k])))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,419 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// tusServer is a minimal tus 1.0 server with the creation, checksum and
+// termination extensions.
+type tusServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	uploads map[string]*tusFile
+	next    int
+	methods []string
+	// extensions is sent in Tus-Extension in reply to OPTIONS.
+	extensions string
+	// failPatch, when set, decides whether a PATCH stores only part of its
+	// body and fails.
+	failPatch func(n int) bool
+}
+
+type tusFile struct {
+	data     []byte
+	length   int64
+	metadata string
+}
+
+func newTusServer(t *testing.T) *tusServer {
+	t.Helper()
+	s := &tusServer{uploads: map[string]*tusFile{}, extensions: "creation,creation-defer-length,checksum,termination"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	old := tusRetryDelay
+	tusRetryDelay = time.Millisecond
+	t.Cleanup(func() { tusRetryDelay = old })
+	return s
+}
+
+func (s *tusServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = append(s.methods, r.Method)
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Method == http.MethodOptions && r.URL.Path == "/files/" {
+		w.Header().Set("Tus-Version", tusVersion)
+		w.Header().Set("Tus-Extension", s.extensions)
+		w.Header().Set("Tus-Checksum-Algorithm", "sha256")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method == http.MethodPost && r.URL.Path == "/files/" {
+		f := &tusFile{length: -1, metadata: r.Header.Get("Upload-Metadata")}
+		if v := r.Header.Get("Upload-Length"); v != "" {
+			f.length, _ = strconv.ParseInt(v, 10, 64)
+		} else if r.Header.Get("Upload-Defer-Length") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.next++
+		id := strconv.Itoa(s.next)
+		s.uploads[id] = f
+		w.Header().Set("Location", "/files/"+id)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	f, ok := s.uploads[strings.TrimPrefix(r.URL.Path, "/files/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(f.data)))
+		if f.length >= 0 {
+			w.Header().Set("Upload-Length", strconv.FormatInt(f.length, 10))
+		}
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(len(f.data)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if v := r.Header.Get("Upload-Length"); v != "" {
+			f.length, _ = strconv.ParseInt(v, 10, 64)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if v := r.Header.Get("Upload-Checksum"); v != "" {
+			sum := sha256.Sum256(body)
+			if v != "sha256 "+base64.StdEncoding.EncodeToString(sum[:]) {
+				w.WriteHeader(statusChecksumMismatch)
+				return
+			}
+		}
+		if s.failPatch != nil && s.failPatch(len(body)) {
+			f.data = append(f.data, body[:len(body)/2]...)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.data = append(f.data, body...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(f.data)))
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(s.uploads, strings.TrimPrefix(r.URL.Path, "/files/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *tusServer) file(t *testing.T, uploadURL string) *tusFile {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.uploads[uploadURL[strings.LastIndex(uploadURL, "/")+1:]]
+	if !ok {
+		t.Fatalf("unknown upload %s", uploadURL)
+	}
+	return f
+}
+
+func (s *tusServer) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, m := range s.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+// streamReader hides the io.Seeker of its reader.
+type streamReader struct{ r io.Reader }
+
+func (s streamReader) Read(p []byte) (int, error) { return s.r.Read(p) }
+
+func TestTusUpload(t *testing.T) {
+	srv := newTusServer(t)
+	data := randomBytes(10 << 10)
+
+	uploadURL, err := TusUpload(context.Background(), New(), srv.URL+"/files/", bytes.NewReader(data),
+		TusChunkSize(4<<10),
+		TusMetadata(map[string]string{"filename": "a.bin"}),
+		TusMetadata(map[string]string{"empty": ""}),
+		TusChecksum(DigestSHA256),
+	)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if !strings.HasPrefix(uploadURL, srv.URL+"/files/") {
+		t.Fatalf("expected absolute upload URL, got %q", uploadURL)
+	}
+	f := srv.file(t, uploadURL)
+	if !bytes.Equal(f.data, data) || f.length != int64(len(data)) {
+		t.Fatalf("server holds %d of %d bytes", len(f.data), f.length)
+	}
+	if want := "empty,filename " + base64.StdEncoding.EncodeToString([]byte("a.bin")); f.metadata != want {
+		t.Fatalf("unexpected metadata %q", f.metadata)
+	}
+	if got := srv.count(http.MethodPatch); got != 3 {
+		t.Fatalf("expected 3 chunks, got %d", got)
+	}
+}
+
+func TestTusUploadDeferredLength(t *testing.T) {
+	srv := newTusServer(t)
+	data := randomBytes(8 << 10)
+
+	uploadURL, err := TusUpload(context.Background(), New(), srv.URL+"/files/", streamReader{bytes.NewReader(data)}, TusChunkSize(4<<10))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	f := srv.file(t, uploadURL)
+	if !bytes.Equal(f.data, data) || f.length != int64(len(data)) {
+		t.Fatalf("server holds %d of %d bytes", len(f.data), f.length)
+	}
+	// Two full chunks, then an empty one declaring the length.
+	if got := srv.count(http.MethodPatch); got != 3 {
+		t.Fatalf("expected 3 chunks, got %d", got)
+	}
+}
+
+func TestTusUploadRecoversFromFailedChunk(t *testing.T) {
+	srv := newTusServer(t)
+	failures := 0
+	srv.failPatch = func(n int) bool {
+		failures++
+		return failures == 2
+	}
+	data := randomBytes(12 << 10)
+
+	uploadURL, err := TusUpload(context.Background(), New(), srv.URL+"/files/", bytes.NewReader(data), TusChunkSize(4<<10))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if f := srv.file(t, uploadURL); !bytes.Equal(f.data, data) {
+		t.Fatalf("server holds %d of %d bytes", len(f.data), len(data))
+	}
+	if got := srv.count(http.MethodHead); got != 1 {
+		t.Fatalf("expected one offset check, got %d", got)
+	}
+}
+
+func TestTusUploadGivesUpWithoutProgress(t *testing.T) {
+	srv := newTusServer(t)
+	srv.failPatch = func(n int) bool { return n > 0 }
+
+	_, err := TusUpload(context.Background(), New(), srv.URL+"/files/", bytes.NewReader(randomBytes(1)), TusChunkSize(4<<10))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected bad gateway error, got %v", err)
+	}
+	if got := srv.count(http.MethodPatch); got != maxTusStalls+1 {
+		t.Fatalf("expected %d attempts, got %d", maxTusStalls+1, got)
+	}
+}
+
+func TestTusUploadResumesAfterRestart(t *testing.T) {
+	srv := newTusServer(t)
+	dir := t.TempDir()
+	data := randomBytes(12 << 10)
+	path := filepath.Join(dir, "video.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	storePath := filepath.Join(dir, "uploads.json")
+
+	patches := 0
+	srv.failPatch = func(int) bool {
+		patches++
+		return patches >= 2
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, err = TusUpload(context.Background(), New(TusResume(NewTusFileStore(storePath))), srv.URL+"/files/", f, TusChunkSize(4<<10))
+	_ = f.Close()
+	if err == nil {
+		t.Fatalf("expected first upload to fail")
+	}
+
+	srv.failPatch = nil
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var events []req.UploadInfo
+	uploadURL, err := TusUpload(context.Background(), New(TusResume(NewTusFileStore(storePath))), srv.URL+"/files/", f,
+		TusChunkSize(4<<10),
+		UploadCallbackWithInterval(func(info req.UploadInfo) { events = append(events, info) }, 0),
+	)
+	if err != nil {
+		t.Fatalf("resumed upload failed: %v", err)
+	}
+	if got := srv.count(http.MethodPost); got != 1 {
+		t.Fatalf("expected the upload to be resumed, got %d creations", got)
+	}
+	if got := srv.file(t, uploadURL); !bytes.Equal(got.data, data) {
+		t.Fatalf("server holds %d of %d bytes", len(got.data), len(data))
+	}
+	if len(events) == 0 || events[0].UploadedSize < 4<<10 || events[0].FileName != "video.bin" {
+		t.Fatalf("expected progress to start at the resumed offset, got %+v", events)
+	}
+	if last := events[len(events)-1]; last.UploadedSize != int64(len(data)) || last.FileSize != int64(len(data)) {
+		t.Fatalf("expected final event at 100%%, got %+v", last)
+	}
+	stored, err := os.ReadFile(storePath)
+	if err != nil || string(stored) != "{}" {
+		t.Fatalf("expected store entry to be removed, got %q (%v)", stored, err)
+	}
+}
+
+func TestTusUploadRestartsWhenServerForgot(t *testing.T) {
+	srv := newTusServer(t)
+	store := NewTusMemoryStore()
+	key := srv.URL + "/files/ report"
+	if err := store.Set(key, srv.URL+"/files/gone"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	uploadURL, err := TusUpload(context.Background(), New(TusResume(store)), srv.URL+"/files/", strings.NewReader("payload"),
+		TusFingerprint("report"),
+	)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if got := srv.file(t, uploadURL); string(got.data) != "payload" {
+		t.Fatalf("unexpected data %q", got.data)
+	}
+	if _, ok := store.Get(key); ok {
+		t.Fatalf("expected store entry to be removed")
+	}
+}
+
+func TestTusUploadProgress(t *testing.T) {
+	srv := newTusServer(t)
+	rec := &recordingRenderer{}
+
+	_, err := TusUpload(context.Background(), New(ProgressTo(rec)), srv.URL+"/files/", bytes.NewReader(randomBytes(8<<10)),
+		TusChunkSize(2<<10),
+		UploadProgress(),
+	)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if len(rec.events) == 0 {
+		t.Fatalf("expected progress events")
+	}
+	for _, ev := range rec.events[1:] {
+		if ev.ID != rec.events[0].ID {
+			t.Fatalf("expected one transfer, got IDs %d and %d", rec.events[0].ID, ev.ID)
+		}
+	}
+	last := rec.events[len(rec.events)-1]
+	if !last.Done || last.Transferred != 8<<10 || last.Total != 8<<10 {
+		t.Fatalf("unexpected final event %+v", last)
+	}
+	for _, ev := range rec.events[:len(rec.events)-1] {
+		if ev.Done {
+			t.Fatalf("unexpected early done event %+v", ev)
+		}
+	}
+}
+
+func TestTusUploadInvalidChecksum(t *testing.T) {
+	_, err := TusUpload(context.Background(), New(), "http://127.0.0.1:1/files/", strings.NewReader("x"), TusChecksum("md4"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported digest algorithm") {
+		t.Fatalf("expected unsupported algorithm error, got %v", err)
+	}
+}
+
+func TestTusUploadChecksumUnsupported(t *testing.T) {
+	srv := newTusServer(t)
+	_, err := TusUpload(context.Background(), New(), srv.URL+"/files/", strings.NewReader("payload"), TusChecksum(DigestSHA512))
+	if err == nil || !strings.Contains(err.Error(), "checksum algorithm sha512") {
+		t.Fatalf("expected unsupported sha512 error, got %v", err)
+	}
+
+	srv.mu.Lock()
+	srv.extensions = "creation,termination"
+	srv.mu.Unlock()
+	_, err = TusUpload(context.Background(), New(), srv.URL+"/files/", strings.NewReader("payload"), TusChecksum(DigestSHA256))
+	if err == nil || !strings.Contains(err.Error(), "checksum algorithm sha256") {
+		t.Fatalf("expected unsupported sha256 error, got %v", err)
+	}
+	if srv.count(http.MethodPost) != 0 || srv.count(http.MethodPatch) != 0 {
+		t.Fatalf("upload started without checksum support")
+	}
+}
+
+func TestTusTerminate(t *testing.T) {
+	srv := newTusServer(t)
+	uploadURL, err := TusUpload(context.Background(), New(), srv.URL+"/files/", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := TusTerminate(context.Background(), New(), uploadURL); err != nil {
+		t.Fatalf("terminate failed: %v", err)
+	}
+	err = TusTerminate(context.Background(), New(), uploadURL)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected not found after termination, got %v", err)
+	}
+}
+
+func TestTusFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store := NewTusFileStore(path)
+	if _, ok := store.Get("a"); ok {
+		t.Fatalf("expected empty store")
+	}
+	for i := range 3 {
+		if err := store.Set(fmt.Sprint(i), fmt.Sprintf("https://example.com/%d", i)); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	if err := store.Delete("1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	reopened := NewTusFileStore(path)
+	if u, ok := reopened.Get("2"); !ok || u != "https://example.com/2" {
+		t.Fatalf("unexpected entry %q %v", u, ok)
+	}
+	if _, ok := reopened.Get("1"); ok {
+		t.Fatalf("expected deleted entry to be gone")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary files, got %d entries", len(entries))
+	}
+}
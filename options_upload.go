@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"strings"
 	"sync"
 	"time"
 
@@ -179,40 +178,7 @@ func (b OptionBuilder) UploadCallback(callback req.UploadCallback) OptionBuilder
 		return b
 	}
 	return b.add(requestOnly(func(r *req.Request) {
-		var mu sync.Mutex
-		var last req.UploadInfo
-		var seen bool
-		var completed bool
-		r.SetUploadCallback(func(info req.UploadInfo) {
-			mu.Lock()
-			last = info
-			seen = true
-			if info.FileSize > 0 && info.UploadedSize >= info.FileSize {
-				completed = true
-			}
-			mu.Unlock()
-			callback(info)
-		})
-		r.OnAfterResponse(func(_ *req.Client, _ *req.Response) error {
-			mu.Lock()
-			info := last
-			seenLocal := seen
-			completedLocal := completed
-			mu.Unlock()
-			if !seenLocal {
-				return nil
-			}
-			if !completedLocal {
-				if info.FileSize == 0 {
-					info.FileSize = info.UploadedSize
-				}
-				if info.FileSize > 0 {
-					info.UploadedSize = info.FileSize
-				}
-				callback(info)
-			}
-			return nil
-		})
+		watchUpload(r, callback, 200*time.Millisecond)
 	}))
 }
 
@@ -248,44 +214,13 @@ func (b OptionBuilder) UploadCallbackWithInterval(callback req.UploadCallback, m
 		return b
 	}
 	return b.add(requestOnly(func(r *req.Request) {
-		var mu sync.Mutex
-		var last req.UploadInfo
-		var seen bool
-		var completed bool
-		r.SetUploadCallbackWithInterval(func(info req.UploadInfo) {
-			mu.Lock()
-			last = info
-			seen = true
-			if info.FileSize > 0 && info.UploadedSize >= info.FileSize {
-				completed = true
-			}
-			mu.Unlock()
-			callback(info)
-		}, minInterval)
-		r.OnAfterResponse(func(_ *req.Client, _ *req.Response) error {
-			mu.Lock()
-			info := last
-			seenLocal := seen
-			completedLocal := completed
-			mu.Unlock()
-			if !seenLocal {
-				return nil
-			}
-			if !completedLocal {
-				if info.FileSize == 0 {
-					info.FileSize = info.UploadedSize
-				}
-				if info.FileSize > 0 {
-					info.UploadedSize = info.FileSize
-				}
-				callback(info)
-			}
-			return nil
-		})
+		watchUpload(r, callback, minInterval)
 	}))
 }
 
-// UploadProgress enables a default progress spinner and bar for uploads.
+// UploadProgress shows upload progress with the transfer rate and estimated
+// time left. Output goes to the renderer set with ProgressTo, or else to
+// stdout, as bars on a terminal and as log lines otherwise.
 // @group Upload Options
 // Applies to individual requests only.
 //
@@ -308,60 +243,160 @@ func UploadProgress() OptionBuilder {
 
 func (b OptionBuilder) UploadProgress() OptionBuilder {
 	return b.add(requestOnly(func(r *req.Request) {
-		var mu sync.Mutex
-		var last string
-		var total int64
-		spin := []string{"|", "/", "-", "\\"}
-		spinIndex := 0
-		barWidth := 20
+		tracker := &progressTracker{renderer: progressRendererFor(r)}
+		id := progressID(r, uploadProgressKey{})
+		tracker.endOnError(r)
+		var started time.Time
+		var base int64
+		watchUpload(r, func(info req.UploadInfo) {
+			now := time.Now()
+			if started.IsZero() {
+				started, base = now, info.UploadedSize
+			}
+			label := progressLabel("upload", r)
+			if info.FileName != "" {
+				label = "upload " + info.FileName
+			}
+			done := info.FileSize > 0 && info.UploadedSize >= info.FileSize
+			rate, eta := transferRate(started, now, info.UploadedSize-base, info.UploadedSize, info.FileSize)
+			tracker.render(ProgressEvent{
+				ID:          id,
+				Label:       label,
+				Transferred: info.UploadedSize,
+				Total:       info.FileSize,
+				Rate:        rate,
+				ETA:         eta,
+				Done:        done,
+			})
+		}, 200*time.Millisecond)
+	}))
+}
 
-		r.SetUploadCallback(func(info req.UploadInfo) {
-			mu.Lock()
-			defer mu.Unlock()
+type uploadProgressKey struct{}
 
+// watchUpload reports the upload progress of r to callback, followed by a
+// final 100% event once the request completes. Requests made by TusUpload
+// carry their own progress, which spans every chunk of the upload.
+func watchUpload(r *req.Request, callback req.UploadCallback, interval time.Duration) {
+	if p, ok := r.Context().Value(uploadProgressKey{}).(*uploadProgress); ok {
+		p.watch(callback, interval)
+		return
+	}
+	var mu sync.Mutex
+	var last req.UploadInfo
+	var seen bool
+	var completed bool
+	r.SetUploadCallbackWithInterval(func(info req.UploadInfo) {
+		mu.Lock()
+		last = info
+		seen = true
+		if info.FileSize > 0 && info.UploadedSize >= info.FileSize {
+			completed = true
+		}
+		mu.Unlock()
+		callback(info)
+	}, interval)
+	r.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		if resp != nil && resp.Err != nil {
+			return nil
+		}
+		mu.Lock()
+		info := last
+		seenLocal := seen
+		completedLocal := completed
+		mu.Unlock()
+		if !seenLocal {
+			return nil
+		}
+		if !completedLocal {
+			if info.FileSize == 0 {
+				info.FileSize = info.UploadedSize
+			}
 			if info.FileSize > 0 {
-				total = info.FileSize
+				info.UploadedSize = info.FileSize
 			}
-			spinIndex = (spinIndex + 1) % len(spin)
+			callback(info)
+		}
+		return nil
+	})
+}
 
-			if total > 0 {
-				percent := float64(info.UploadedSize) / float64(total) * 100
-				filled := int(percent / 100 * float64(barWidth))
-				bar := strings.Repeat("=", filled) + strings.Repeat("-", barWidth-filled)
-				last = fmt.Sprintf(
-					"\r%s upload [%s] %.1f%% (%s/%s)",
-					spin[spinIndex],
-					bar,
-					percent,
-					formatBytes(info.UploadedSize),
-					formatBytes(total),
-				)
-			} else {
-				last = fmt.Sprintf("\r%s upload %s", spin[spinIndex], formatBytes(info.UploadedSize))
-			}
-			fmt.Print(last)
-		})
+// uploadProgress tracks an upload made of several requests and reports it to
+// a callback as one transfer.
+type uploadProgress struct {
+	mu       sync.Mutex
+	id       int64
+	info     req.UploadInfo
+	callback req.UploadCallback
+	interval time.Duration
+	last     time.Time
+	reported bool
+}
 
-		r.OnAfterResponse(func(_ *req.Client, _ *req.Response) error {
-			mu.Lock()
-			defer mu.Unlock()
-			if last == "" {
-				return nil
-			}
-			if total > 0 {
-				fmt.Printf(
-					"\r%s upload [%s] 100.0%% (%s/%s)\n",
-					spin[spinIndex],
-					strings.Repeat("=", barWidth),
-					formatBytes(total),
-					formatBytes(total),
-				)
-			} else {
-				fmt.Print("\n")
-			}
-			return nil
-		})
-	}))
+func (p *uploadProgress) progressID() int64 {
+	return p.id
+}
+
+func (p *uploadProgress) watch(callback req.UploadCallback, interval time.Duration) {
+	p.mu.Lock()
+	p.callback = callback
+	p.interval = interval
+	p.mu.Unlock()
+}
+
+// set moves the upload to uploaded bytes.
+func (p *uploadProgress) set(uploaded int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.info.UploadedSize = uploaded
+	if p.info.FileSize > 0 && uploaded >= p.info.FileSize {
+		// Leave the 100% event to finish, once the server confirmed it.
+		return
+	}
+	if p.callback == nil {
+		return
+	}
+	now := time.Now()
+	if p.interval > 0 && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	p.callback(p.info)
+}
+
+func (p *uploadProgress) add(n int64) {
+	p.mu.Lock()
+	uploaded := p.info.UploadedSize + n
+	p.mu.Unlock()
+	p.set(uploaded)
+}
+
+// finish delivers the 100% event.
+func (p *uploadProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.callback == nil || p.reported {
+		return
+	}
+	if p.info.FileSize < p.info.UploadedSize {
+		p.info.FileSize = p.info.UploadedSize
+	}
+	p.info.UploadedSize = p.info.FileSize
+	p.reported = true
+	p.callback(p.info)
+}
+
+type uploadReader struct {
+	r        io.Reader
+	progress *uploadProgress
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.progress.add(int64(n))
+	}
+	return n, err
 }
 
 func formatBytes(size int64) string {